@@ -15,7 +15,7 @@ func (api *API) GetAddrBal(c context.Context, hash string, params map[string]str
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &addr)
+	err = api.getCached(c, u, &addr, true, api.keepMutable)
 	return
 }
 
@@ -25,35 +25,20 @@ func (api *API) GetMultiAddrBal(c context.Context, hashes []string) (addr []Addr
 	return
 }
 
+//GetMultiAddrBalCustom returns balance information for
+//any number of addresses, in order, fetching them in
+//batches. It fails if any of them failed.
 func (api *API) GetMultiAddrBalCustom(c context.Context, hashes []string, omitWalletAddr bool) (addr []Addr, err error) {
 	if len(hashes) == 0 {
 		return
 	}
 	params := map[string]string{"omitWalletAddresses": strconv.FormatBool(omitWalletAddr)}
-	hash := ""
-	for i, v := range hashes {
-		if i == 0 {
-			hash = v
-		} else {
-			hash = hash + ";" + v
+	results := batchGet(c, api, hashes, func(ids string) string { return "/addrs/" + ids + "/balance" }, params, addrKeys)
+	for _, v := range results {
+		if v.Err != nil {
+			return nil, v.Err
 		}
-	}
-	u, err := api.buildURL("/addrs/"+hash+"/balance", params)
-	if err != nil {
-		return
-	}
-	if len(hashes) > 1 {
-		err = getResponse(c, u, &addr)
-		if err != nil {
-			return
-		}
-	} else {
-		var add Addr
-		err = getResponse(c, u, &add)
-		if err != nil {
-			return
-		}
-		addr = []Addr{add}
+		addr = append(addr, v.Value)
 	}
 	return
 }
@@ -68,13 +53,16 @@ func (api *API) GetAddr(c context.Context, hash string, params map[string]string
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &addr)
+	err = api.getResponse(c, u, &addr)
 	return
 }
 
 //GetAddrNext returns a given Addr's next page of TXRefs,
 //if Addr.HasMore is true. If HasMore is false, will
 //return an error. It assumes default API URL parameters.
+//Pages may repeat or skip TXRefs when they end in the middle
+//of a block; use NewAddrCursor or AddrHistory to walk a
+//whole history.
 func (api *API) GetAddrNext(c context.Context, this Addr) (next Addr, err error) {
 	if !this.HasMore {
 		err = errors.New("Func GetAddrNext: this Addr doesn't have more TXRefs according to its HasMore")
@@ -94,13 +82,15 @@ func (api *API) GetAddrFull(c context.Context, hash string, params map[string]st
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &addr)
+	err = api.getResponse(c, u, &addr)
 	return
 }
 
 //GetAddrFullNext returns a given Addr's next page of TXs,
 //if Addr.HasMore is true. If HasMore is false, will
 //return an error. It assumes default API URL parameters, like GetAddrFull.
+//Like GetAddrNext, it may repeat or skip TXs; use NewAddrFullCursor
+//or AddrFullHistory to walk a whole history.
 func (api *API) GetAddrFullNext(c context.Context, this Addr) (next Addr, err error) {
 	if !this.HasMore {
 		err = errors.New("Func GetAddrFullNext: this Addr doesn't have more TXs according to its HasMore")
@@ -115,12 +105,13 @@ func (api *API) GetAddrFullNext(c context.Context, this Addr) (next Addr, err er
 //transactions within the specified coin/chain. Please note that
 //this call must be made over SSL, and it is not recommended to keep
 //large amounts in these addresses, or for very long.
+//GenerateKeychain makes key pairs locally instead.
 func (api *API) GenAddrKeychain(c context.Context) (pair AddrKeychain, err error) {
 	u, err := api.buildURL("/addrs", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, nil, &pair)
+	err = api.postResponse(c, u, nil, &pair)
 	return
 }
 
@@ -138,16 +129,16 @@ func (api *API) GenAddrMultisig(c context.Context, multi AddrKeychain) (addr Add
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &multi, &addr)
+	err = api.postResponse(c, u, &multi, &addr)
 	return
 }
 
-//Faucet funds the AddrKeychain with an amount. Only works on BlockCypher's
-//Testnet and Bitcoin Testnet3. Returns the transaction hash funding
+//Faucet funds the AddrKeychain with an amount. Only works on networks
+//with a Faucet, like BlockCypher's Testnet and Bitcoin Testnet3. Returns the transaction hash funding
 //your AddrKeychain.
 func (api *API) Faucet(c context.Context, a AddrKeychain, amount int) (txhash string, err error) {
-	if !(api.Coin == "bcy" && api.Chain == "test") && !(api.Coin == "btc" && api.Chain == "test3") {
-		err = errors.New("Faucet: Cannot use Faucet unless on BlockCypher Testnet or Bitcoin Testnet3.")
+	if n, ok := LookupNetwork(api.Coin, api.Chain); !ok || !n.Faucet {
+		err = errors.New("Faucet: Cannot use Faucet unless on a test network with a faucet, like BlockCypher Testnet or Bitcoin Testnet3.")
 		return
 	}
 	u, err := api.buildURL("/faucet", nil)
@@ -166,7 +157,7 @@ func (api *API) Faucet(c context.Context, a AddrKeychain, amount int) (txhash st
 		addr = a.Address
 	}
 	txref := make(map[string]string)
-	err = postResponse(c, u, &FauxAddr{addr, amount}, &txref)
+	err = api.postResponse(c, u, &FauxAddr{addr, amount}, &txref)
 	txhash = txref["tx_ref"]
 	return
 }
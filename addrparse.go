@@ -0,0 +1,263 @@
+package gobcy
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"golang.org/x/crypto/sha3"
+)
+
+//AddrType is the kind of an address, which decides
+//the output script paying it.
+type AddrType int
+
+const (
+	P2PKH AddrType = iota + 1
+	P2SH
+	P2WPKH
+	P2WSH
+	P2TR
+	//EthAddr is an Ethereum account, which has no output script.
+	EthAddr
+)
+
+//String returns BlockCypher's script type for the address
+//type, e.g. "pay-to-pubkey-hash", or "ethereum".
+func (t AddrType) String() string {
+	switch t {
+	case P2PKH:
+		return "pay-to-pubkey-hash"
+	case P2SH:
+		return "pay-to-script-hash"
+	case P2WPKH:
+		return "pay-to-witness-pubkey-hash"
+	case P2WSH:
+		return "pay-to-witness-script-hash"
+	case P2TR:
+		return "pay-to-taproot"
+	case EthAddr:
+		return "ethereum"
+	}
+	return "unknown"
+}
+
+//DecodedAddr is an address checked and decoded by ParseAddress.
+type DecodedAddr struct {
+	Network Network
+	Type    AddrType
+	//Address is the address as it was given.
+	Address string
+	//Hash is the public key hash, script hash, witness
+	//program or Ethereum account of the address.
+	Hash []byte
+	//Script is the output script paying the address,
+	//nil for Ethereum addresses.
+	Script []byte
+}
+
+//ParseAddress checks an address of the given coin/chain offline
+//and decodes it. It takes Base58Check (P2PKH and P2SH), Bech32
+//(P2WPKH and P2WSH) and Bech32m (P2TR) addresses, and hex
+//Ethereum addresses, with or without "0x", whose EIP-55 checksum
+//is checked if they are mixed case. Addresses of other networks
+//are rejected.
+func ParseAddress(coin, chain, s string) (addr DecodedAddr, err error) {
+	n, ok := LookupNetwork(coin, chain)
+	if !ok {
+		err = errors.New("ParseAddress: unknown network " + coin + "/" + chain)
+		return
+	}
+	addr = DecodedAddr{Network: n, Address: s}
+	switch {
+	case n.Account:
+		err = addr.parseEth(s)
+	case isBech32(s):
+		err = addr.parseSegwit(s)
+	default:
+		err = addr.parseBase58(s)
+	}
+	if err != nil {
+		return DecodedAddr{}, errors.New("ParseAddress: " + s + ": " + err.Error())
+	}
+	return
+}
+
+//ParseAddress is ParseAddress for the API's coin/chain.
+func (api *API) ParseAddress(s string) (addr DecodedAddr, err error) {
+	return ParseAddress(api.Coin, api.Chain, s)
+}
+
+//parseBase58 decodes a P2PKH or P2SH address.
+func (addr *DecodedAddr) parseBase58(s string) error {
+	hash, version, err := base58.CheckDecode(s)
+	if err != nil {
+		return err
+	}
+	if len(hash) != 20 {
+		return errors.New("wrong length")
+	}
+	addr.Hash = hash
+	switch version {
+	case addr.Network.PubKeyHashVersion:
+		addr.Type = P2PKH
+		addr.Script = append(append([]byte{0x76, 0xa9, 0x14}, hash...), 0x88, 0xac)
+	case addr.Network.ScriptHashVersion:
+		addr.Type = P2SH
+		addr.Script = append(append([]byte{0xa9, 0x14}, hash...), 0x87)
+	default:
+		return wrongNetwork(addr.Network, func(n Network) bool {
+			return !n.Account && (version == n.PubKeyHashVersion || version == n.ScriptHashVersion)
+		})
+	}
+	return nil
+}
+
+//isBech32 reports whether s is Bech32 or Bech32m encoded,
+//which a Base58Check address can't be.
+func isBech32(s string) bool {
+	_, _, _, err := bech32.DecodeGeneric(s)
+	return err == nil
+}
+
+//parseSegwit decodes a native segwit address: Bech32 for
+//witness version 0, Bech32m for the later ones.
+func (addr *DecodedAddr) parseSegwit(s string) error {
+	hrp, data, encoding, err := bech32.DecodeGeneric(s)
+	if err != nil {
+		return err
+	}
+	if addr.Network.Bech32HRP == "" || hrp != addr.Network.Bech32HRP {
+		return wrongNetwork(addr.Network, func(n Network) bool { return n.Bech32HRP == hrp })
+	}
+	if len(data) < 1 {
+		return errors.New("missing witness version")
+	}
+	version := data[0]
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version == 0 && encoding != bech32.Version0, version > 0 && encoding != bech32.VersionM:
+		return errors.New("wrong checksum variant for witness version " + strconv.Itoa(int(version)))
+	case version == 0 && len(program) == 20:
+		addr.Type = P2WPKH
+	case version == 0 && len(program) == 32:
+		addr.Type = P2WSH
+	case version == 1 && len(program) == 32:
+		addr.Type = P2TR
+	case version > 16 || len(program) < 2 || len(program) > 40 || version == 0:
+		return errors.New("invalid witness program")
+	default:
+		return errors.New("unsupported witness version")
+	}
+	op := byte(0)
+	if version > 0 {
+		op = 0x50 + version
+	}
+	addr.Hash = program
+	addr.Script = append([]byte{op, byte(len(program))}, program...)
+	return nil
+}
+
+//parseEth decodes an Ethereum address.
+func (addr *DecodedAddr) parseEth(s string) error {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	hash, err := hex.DecodeString(digits)
+	if err != nil {
+		return err
+	}
+	if len(hash) != 20 {
+		return errors.New("wrong length")
+	}
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && digits != ethChecksum(digits) {
+		return errors.New("bad EIP-55 checksum")
+	}
+	addr.Type = EthAddr
+	addr.Hash = hash
+	return nil
+}
+
+//ethChecksum returns the EIP-55 mixed case form of
+//hex Ethereum address digits.
+func ethChecksum(digits string) string {
+	lower := strings.ToLower(digits)
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	sum := h.Sum(nil)
+	out := []byte(lower)
+	for i, c := range out {
+		if c >= 'a' && sum[i/2]>>(4*(1-uint(i%2)))&0xf >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+//wrongNetwork returns an error for an address of another
+//network, naming it if owns finds one.
+func wrongNetwork(want Network, owns func(Network) bool) error {
+	for _, n := range networks {
+		if owns(n) {
+			return errors.New("address of " + n.String() + ", not " + want.String())
+		}
+	}
+	return errors.New("not an address of " + want.String())
+}
+
+//ValidateTX checks offline the addresses of a TX made by
+//TempNewTX, TempMultiTX or by hand against the given
+//coin/chain with ParseAddress, before it is passed to NewTX.
+//Addresses of inputs and outputs with a "multisig-n-of-m"
+//ScriptType must instead be hex public keys, and inputs
+//spending from a WalletName are skipped.
+func ValidateTX(coin, chain string, trans TX) (err error) {
+	check := func(where, scriptType string, addrs []string) error {
+		for _, a := range addrs {
+			if strings.HasPrefix(scriptType, "multisig-") {
+				if pub, err := hex.DecodeString(a); err != nil || (len(pub) != 33 && len(pub) != 65) {
+					return errors.New("ValidateTX: " + where + ": " + a + " is not a hex public key")
+				}
+				continue
+			}
+			if _, err := ParseAddress(coin, chain, a); err != nil {
+				return errors.New("ValidateTX: " + where + ": " + err.Error())
+			}
+		}
+		return nil
+	}
+	if len(trans.Inputs) == 0 || len(trans.Outputs) == 0 {
+		return errors.New("ValidateTX: a TX needs inputs and outputs")
+	}
+	for i, in := range trans.Inputs {
+		where := "input " + strconv.Itoa(i)
+		if in.WalletName != "" {
+			continue
+		}
+		if len(in.Addresses) == 0 && in.PrevHash == "" {
+			return errors.New("ValidateTX: " + where + " has no addresses")
+		}
+		if err = check(where, in.ScriptType, in.Addresses); err != nil {
+			return
+		}
+	}
+	for i, out := range trans.Outputs {
+		where := "output " + strconv.Itoa(i)
+		if len(out.Addresses) == 0 && out.Script == "" && out.DataHex == "" {
+			return errors.New("ValidateTX: " + where + " has no addresses")
+		}
+		if err = check(where, out.ScriptType, out.Addresses); err != nil {
+			return
+		}
+	}
+	return
+}
+
+//ValidateTX is ValidateTX for the API's coin/chain.
+func (api *API) ValidateTX(trans TX) error {
+	return ValidateTX(api.Coin, api.Chain, trans)
+}
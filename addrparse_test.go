@@ -0,0 +1,66 @@
+package gobcy
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	for _, v := range []struct {
+		coin, chain, addr string
+		typ               AddrType
+		script            string
+	}{
+		{"btc", "main", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", P2PKH, "76a91477bff20c60e522dfaa3350c39b030a5d004e839a88ac"},
+		{"btc", "main", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", P2SH, "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87"},
+		{"btc", "main", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", P2WPKH, "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"btc", "test3", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", P2WSH, "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"btc", "main", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", P2TR, "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+		{"eth", "main", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", EthAddr, ""},
+		{"eth", "main", "fb6916095ca1df60bb79ce92ce3ea74c37c5d359", EthAddr, ""},
+	} {
+		addr, err := ParseAddress(v.coin, v.chain, v.addr)
+		if err != nil || addr.Type != v.typ || hex.EncodeToString(addr.Script) != v.script {
+			t.Errorf("ParseAddress %v returned %v, %x, %v, expected %v, %v", v.addr, addr.Type, addr.Script, err, v.typ, v.script)
+		}
+	}
+	for _, v := range []struct{ coin, chain, addr string }{
+		//wrong network
+		{"btc", "test3", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{"btc", "main", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"},
+		{"doge", "main", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		//bad checksums, and Bech32 instead of Bech32m for a v1 program
+		{"btc", "main", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"},
+		{"btc", "main", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},
+		{"btc", "main", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx"},
+		{"eth", "main", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+		{"btc", "nope", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+	} {
+		if _, err := ParseAddress(v.coin, v.chain, v.addr); err == nil {
+			t.Errorf("Expected error parsing %v on %v/%v, did not receive one", v.addr, v.coin, v.chain)
+		}
+	}
+	api := API{Coin: "bcy", Chain: "test"}
+	keys, err := api.GenerateKeychain(P2PKH)
+	if err != nil {
+		t.Fatal("GenerateKeychain error encountered: ", err)
+	}
+	if err := api.ValidateTX(TempNewTX(keys.Address, keys.Address, *big.NewInt(1000))); err != nil {
+		t.Error("ValidateTX error encountered: ", err)
+	}
+	if err := api.ValidateTX(TempNewTX(keys.Address, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", *big.NewInt(1000))); err == nil {
+		t.Error("Expected error from a btc/main output on bcy/test, did not receive one")
+	}
+	multi, err := TempMultiTX(keys.Address, "", *big.NewInt(1000), 1, []string{keys.Public, keys.Public})
+	if err != nil {
+		t.Fatal("TempMultiTX error encountered: ", err)
+	}
+	if err = api.ValidateTX(multi); err != nil {
+		t.Error("ValidateTX error encountered on a multisig TX: ", err)
+	}
+	multi.Outputs[0].Addresses[1] = keys.Address
+	if err = api.ValidateTX(multi); err == nil {
+		t.Error("Expected error from an address among multisig pubkeys, did not receive one")
+	}
+}
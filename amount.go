@@ -0,0 +1,163 @@
+package gobcy
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+//Amount is a value of a network's coin, kept exactly in base
+//units (satoshis, wei...) and converted to and from any of the
+//network's Units without rounding. It encodes to JSON as the
+//bare number of base units, like the API's own fields, e.g.
+//	NewAmount(n, &out.Value).Format("mBTC")
+//	NewAmount(n, big.NewInt(int64(in.OutputValue))).String()
+//The zero value is 0 base units of no particular network.
+type Amount struct {
+	n    Network
+	base big.Int
+}
+
+//NewAmount returns base units of n's coin as an Amount.
+func NewAmount(n Network, base *big.Int) (a Amount) {
+	a.n = n
+	if base != nil {
+		a.base.Set(base)
+	}
+	return
+}
+
+//Amount returns base units of the API's coin as an Amount.
+func (api *API) Amount(base *big.Int) (a Amount, err error) {
+	n, err := api.Network()
+	if err != nil {
+		return
+	}
+	return NewAmount(n, base), nil
+}
+
+//ParseAmount reads an amount of n's coin written as a decimal
+//number and one of n's Units, e.g. "0.015 BTC", "1500000 sat"
+//or "2.5 gwei". Unit names are matched case-insensitively if
+//there is no exact match. Amounts finer than the base unit
+//are rejected rather than rounded.
+func ParseAmount(n Network, s string) (a Amount, err error) {
+	num, unit, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		err = errors.New("ParseAmount: expected a number and a unit, got \"" + s + "\"")
+		return
+	}
+	decimals, err := n.unitDecimals(strings.TrimSpace(unit))
+	if err != nil {
+		return
+	}
+	neg := strings.HasPrefix(num, "-")
+	num = strings.TrimPrefix(num, "-")
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole == "" && frac == "" || strings.TrimLeft(whole+frac, "0123456789") != "" {
+		err = errors.New("ParseAmount: invalid number \"" + num + "\"")
+		return
+	}
+	if len(strings.TrimRight(frac, "0")) > decimals {
+		err = errors.New("ParseAmount: \"" + s + "\" is finer than one " + n.BaseUnit)
+		return
+	}
+	frac = strings.TrimRight(frac, "0")
+	digits := whole + frac + strings.Repeat("0", decimals-len(frac))
+	if _, ok = a.base.SetString("0"+digits, 10); !ok {
+		err = errors.New("ParseAmount: invalid number \"" + num + "\"")
+		return
+	}
+	if neg {
+		a.base.Neg(&a.base)
+	}
+	a.n = n
+	return
+}
+
+//unitDecimals returns the decimal places of one of n's units.
+func (n Network) unitDecimals(unit string) (int, error) {
+	if d, ok := n.Units[unit]; ok {
+		return d, nil
+	}
+	for k, d := range n.Units {
+		if strings.EqualFold(k, unit) {
+			return d, nil
+		}
+	}
+	return 0, errors.New("Amount: unknown unit \"" + unit + "\" for " + n.String())
+}
+
+//Network returns the network of the amount.
+func (a Amount) Network() Network {
+	return a.n
+}
+
+//Base returns the amount in base units.
+func (a Amount) Base() *big.Int {
+	return new(big.Int).Set(&a.base)
+}
+
+//In returns the amount in the given unit as an exact
+//decimal number, without trailing zeros, e.g. "0.015".
+func (a Amount) In(unit string) (string, error) {
+	decimals, err := a.n.unitDecimals(unit)
+	if err != nil {
+		return "", err
+	}
+	digits := new(big.Int).Abs(&a.base).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if frac != "" {
+		whole += "." + frac
+	}
+	if a.base.Sign() < 0 {
+		whole = "-" + whole
+	}
+	return whole, nil
+}
+
+//Format returns the amount in the given unit followed by
+//the unit's name, e.g. "0.015 BTC", or the amount in base
+//units if the network has no such unit.
+func (a Amount) Format(unit string) string {
+	num, err := a.In(unit)
+	if err != nil {
+		return a.base.String() + " " + a.n.BaseUnit
+	}
+	return num + " " + unit
+}
+
+//String formats the amount in the network's main unit,
+//or as a bare number of base units without a network.
+func (a Amount) String() string {
+	if a.n.Unit == "" {
+		return a.base.String()
+	}
+	return a.Format(a.n.Unit)
+}
+
+//Cmp compares the amounts' values in base units.
+func (a Amount) Cmp(b Amount) int {
+	return a.base.Cmp(&b.base)
+}
+
+//MarshalJSON encodes the amount as a number of base units.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return a.base.MarshalJSON()
+}
+
+//UnmarshalJSON decodes a number of base units, keeping
+//the amount's network.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	//decode into fresh storage: copies of a share
+	//the digits of its big.Int
+	var b big.Int
+	if err := b.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	a.base = b
+	return nil
+}
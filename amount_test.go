@@ -0,0 +1,61 @@
+package gobcy
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestAmount(t *testing.T) {
+	btc, _ := LookupNetwork("btc", "main")
+	a, err := ParseAmount(btc, "0.015 BTC")
+	if err != nil || a.Base().Int64() != 1500000 {
+		t.Fatalf("Expected 1500000 satoshis, got %v, %v", a.Base(), err)
+	}
+	if s, _ := a.In("mBTC"); s != "15" || a.Format("sat") != "1500000 sat" || a.String() != "0.015 BTC" {
+		t.Errorf("Unexpected conversions of %v: %v, %v", a.Base(), s, a.Format("sat"))
+	}
+	if b, err := ParseAmount(btc, "1500000 sat"); err != nil || b.Cmp(a) != 0 {
+		t.Errorf("Expected 1500000 sat to equal 0.015 BTC, got %v, %v", b, err)
+	}
+	for _, bad := range []string{"0.000000001 BTC", "1.5", "1.5 XYZ", "1e5 sat", "- BTC"} {
+		if _, err = ParseAmount(btc, bad); err == nil {
+			t.Errorf("Expected error from ParseAmount(%q), did not receive one", bad)
+		}
+	}
+	eth, _ := LookupNetwork("eth", "main")
+	g, err := ParseAmount(eth, "2.5 gwei")
+	if err != nil || g.Base().String() != "2500000000" {
+		t.Errorf("Expected 2500000000 wei, got %v, %v", g.Base(), err)
+	}
+	if s, _ := g.In("ETH"); s != "0.0000000025" {
+		t.Errorf("Expected 0.0000000025 ETH, got %v", s)
+	}
+	api := API{Coin: "bcy", Chain: "test"}
+	balance := big.NewInt(1e6)
+	bal, err := api.Amount(balance)
+	if err != nil || bal.String() != "0.01 BCY" {
+		t.Errorf("Expected a balance of 0.01 BCY, got %v, %v", bal, err)
+	}
+	data, err := json.Marshal(struct{ Value Amount }{bal})
+	var out struct{ Value big.Int }
+	if err != nil || json.Unmarshal(data, &out) != nil || out.Value.Cmp(balance) != 0 {
+		t.Errorf("Expected Amount to encode like big.Int, got %s, %v", data, err)
+	}
+}
+
+func TestAmountCopy(t *testing.T) {
+	btc, _ := LookupNetwork("btc", "main")
+	a, err := ParseAmount(btc, "0.015 BTC")
+	if err != nil {
+		t.Fatal("ParseAmount error encountered: ", err)
+	}
+	//decoding into a copy leaves the original alone
+	s := struct{ V Amount }{a}
+	if err = json.Unmarshal([]byte(`{"V": 5}`), &s); err != nil {
+		t.Fatal("Unmarshal error encountered: ", err)
+	}
+	if a.Base().Int64() != 1500000 || s.V.Base().Int64() != 5 || s.V.Network().Coin != "btc" {
+		t.Errorf("Expected 1500000 and 5 btc base units, got %v and %v", a.Base(), s.V.Base())
+	}
+}
@@ -0,0 +1,19 @@
+package gobcy
+
+import (
+	"net/http"
+
+	"google.golang.org/appengine/v2/urlfetch"
+)
+
+//URLFetchTransport is an http.RoundTripper that sends requests
+//through App Engine's urlfetch service, using the context of
+//each request. It is the default transport of an API without
+//an HTTPClient, and only works inside an App Engine app.
+type URLFetchTransport struct{}
+
+//RoundTrip implements http.RoundTripper.
+func (URLFetchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr := urlfetch.Transport{Context: req.Context()}
+	return tr.RoundTrip(req)
+}
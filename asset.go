@@ -11,7 +11,7 @@ func (api *API) GenAssetKeychain(c context.Context) (pair AddrKeychain, err erro
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, nil, &pair)
+	err = api.postResponse(c, u, nil, &pair)
 	return
 }
 
@@ -23,7 +23,7 @@ func (api *API) IssueAsset(c context.Context, issue OAPIssue) (tx OAPTX, err err
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &issue, &tx)
+	err = api.postResponse(c, u, &issue, &tx)
 	return
 }
 
@@ -34,7 +34,7 @@ func (api *API) TransferAsset(c context.Context, issue OAPIssue, assetID string)
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &issue, &tx)
+	err = api.postResponse(c, u, &issue, &tx)
 	return
 }
 
@@ -45,7 +45,7 @@ func (api *API) ListAssetTXs(c context.Context, assetID string) (txs []string, e
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &txs)
+	err = api.getResponse(c, u, &txs)
 	return
 }
 
@@ -56,7 +56,7 @@ func (api *API) GetAssetTX(c context.Context, assetID, hash string) (tx OAPTX, e
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &tx)
+	err = api.getResponse(c, u, &tx)
 	return
 }
 
@@ -69,6 +69,6 @@ func (api *API) GetAssetAddr(c context.Context, assetID, oapAddr string) (addr A
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &addr)
+	err = api.getResponse(c, u, &addr)
 	return
 }
@@ -0,0 +1,146 @@
+package gobcy
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//BalancePoint is the confirmed balance of an address at a
+//block height (from BalanceSeries) or at a time (from
+//BalanceSeriesTime), in the coin's base units.
+type BalancePoint struct {
+	Height  int
+	Time    time.Time
+	Balance big.Int
+}
+
+//balanceDelta is how much a confirmed TXRef changed
+//the balance of its address.
+type balanceDelta struct {
+	height int
+	time   time.Time
+	value  big.Int
+}
+
+//BalanceAt returns the confirmed balance the given address
+//(or wallet name) had at the end of the block at height,
+//by replaying its TXRefs up to that block.
+func (api *API) BalanceAt(c context.Context, hash string, height int) (bal big.Int, err error) {
+	points, err := api.BalanceSeries(c, hash, height, height, 1)
+	if err != nil {
+		return
+	}
+	bal.Set(&points[0].Balance)
+	return
+}
+
+//BalanceAtTime returns the confirmed balance the given address
+//(or wallet name) had at time t, counting the transactions
+//confirmed at or before t.
+func (api *API) BalanceAtTime(c context.Context, hash string, t time.Time) (bal big.Int, err error) {
+	points, err := api.BalanceSeriesTime(c, hash, []time.Time{t})
+	if err != nil {
+		return
+	}
+	bal.Set(&points[0].Balance)
+	return
+}
+
+//BalanceSeries returns the confirmed balances of the given
+//address (or wallet name) at the heights from, from+step, and
+//so on, up to and always including to. The history is only
+//fetched once, and only up to height to.
+func (api *API) BalanceSeries(c context.Context, hash string, from, to, step int) (points []BalancePoint, err error) {
+	if from < 0 || to < from || step < 1 {
+		err = errors.New("BalanceSeries: need 0 <= from <= to and a positive step")
+		return
+	}
+	deltas, err := api.balanceDeltas(c, hash, to)
+	if err != nil {
+		return
+	}
+	for h := from; ; h += step {
+		if h > to {
+			h = to
+		}
+		points = append(points, BalancePoint{Height: h})
+		if h == to {
+			break
+		}
+	}
+	runningBalance(deltas, points, func(d *balanceDelta, p *BalancePoint) bool { return d.height <= p.Height })
+	return
+}
+
+//BalanceSeriesTime returns the confirmed balances of the given
+//address (or wallet name) at each of the given times, like the
+//ends of months, in ascending order. The whole history is
+//fetched once.
+func (api *API) BalanceSeriesTime(c context.Context, hash string, times []time.Time) (points []BalancePoint, err error) {
+	if len(times) == 0 {
+		err = errors.New("BalanceSeriesTime: no times given")
+		return
+	}
+	deltas, err := api.balanceDeltas(c, hash, -1)
+	if err != nil {
+		return
+	}
+	for _, d := range deltas {
+		if d.time.IsZero() {
+			err = errors.New("BalanceSeriesTime: a confirmed TXRef of " + hash + " has no confirmation time")
+			return
+		}
+	}
+	for _, t := range times {
+		points = append(points, BalancePoint{Time: t})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	sort.SliceStable(deltas, func(i, j int) bool { return deltas[i].time.Before(deltas[j].time) })
+	runningBalance(deltas, points, func(d *balanceDelta, p *BalancePoint) bool { return !d.time.After(p.Time) })
+	return
+}
+
+//balanceDeltas returns the balance changes from the confirmed
+//TXRefs of the given address up to height (or all of them if
+//height is negative), oldest first.
+func (api *API) balanceDeltas(c context.Context, hash string, height int) (deltas []balanceDelta, err error) {
+	opts := &AddrOptions{Limit: 2000}
+	if height >= 0 {
+		opts.Before = height + 1
+	}
+	for ref, err := range api.AddrHistory(c, hash, opts) {
+		if err != nil {
+			return nil, err
+		}
+		if ref.BlockHeight < 0 || ref.Confirmations == 0 || (height >= 0 && ref.BlockHeight > height) {
+			continue
+		}
+		d := balanceDelta{height: ref.BlockHeight, time: ref.Confirmed}
+		if ref.TXOutputN >= 0 {
+			d.value.Set(&ref.Value)
+		} else {
+			d.value.Neg(&ref.Value)
+		}
+		deltas = append(deltas, d)
+	}
+	//the history comes newest first
+	sort.SliceStable(deltas, func(i, j int) bool { return deltas[i].height < deltas[j].height })
+	return
+}
+
+//runningBalance sets the Balance of each of the ascending points
+//to the sum of the ascending deltas for which counts holds.
+func runningBalance(deltas []balanceDelta, points []BalancePoint, counts func(*balanceDelta, *BalancePoint) bool) {
+	var bal big.Int
+	i := 0
+	for p := range points {
+		for ; i < len(deltas) && counts(&deltas[i], &points[p]); i++ {
+			bal.Add(&bal, &deltas[i].value)
+		}
+		points[p].Balance.Set(&bal)
+	}
+}
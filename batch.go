@@ -0,0 +1,296 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+//MaxBatchSize is the largest number of ids BlockCypher
+//accepts in a single batch request.
+const MaxBatchSize = 100
+
+//BatchPolicy tunes batch calls like GetTXs. Zero
+//fields take the defaults noted.
+type BatchPolicy struct {
+	//Size is the number of ids per request, up to
+	//MaxBatchSize (the default). Lower it if the token's
+	//per-second limit can't cover a full batch.
+	Size int
+	//Concurrency caps the requests in flight; 4 by default.
+	Concurrency int
+}
+
+//BatchResult is the outcome for one id of a batch call.
+//Err is set if that id (or the request carrying it) failed;
+//ids BlockCypher reports as missing match ErrNotFound.
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+//size returns the batch size to use.
+func (p *BatchPolicy) size() int {
+	if p == nil || p.Size <= 0 || p.Size > MaxBatchSize {
+		return MaxBatchSize
+	}
+	return p.Size
+}
+
+//concurrency returns the number of requests allowed in flight.
+func (p *BatchPolicy) concurrency() int {
+	if p == nil || p.Concurrency <= 0 {
+		return 4
+	}
+	return p.Concurrency
+}
+
+//GetTXs returns the TXs with the given hashes, in the same
+//order, fetching them in batches. opts may be nil.
+func (api *API) GetTXs(c context.Context, hashes []string, opts *TXOptions) (results []BatchResult[TX], err error) {
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	results = batchGet(c, api, hashes, func(ids string) string { return "/txs/" + ids }, params,
+		func(tx TX) []string { return []string{tx.Hash} })
+	return
+}
+
+//GetBlocks returns the Blocks with the given hashes or
+//heights, in the same order, fetching them in batches.
+//opts may be nil.
+func (api *API) GetBlocks(c context.Context, ids []string, opts *BlockOptions) (results []BatchResult[Block], err error) {
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	results = batchGet(c, api, ids, func(ids string) string { return "/blocks/" + ids }, params,
+		func(b Block) []string { return []string{b.Hash, strconv.Itoa(b.Height)} })
+	return
+}
+
+//GetAddrs is GetAddrOpts for many addresses, returned in
+//the same order and fetched in batches. opts may be nil.
+func (api *API) GetAddrs(c context.Context, hashes []string, opts *AddrOptions) (results []BatchResult[Addr], err error) {
+	return api.getAddrs(c, hashes, "", 2000, opts)
+}
+
+//GetAddrsFull is GetAddrFullOpts for many addresses, returned
+//in the same order and fetched in batches. opts may be nil.
+func (api *API) GetAddrsFull(c context.Context, hashes []string, opts *AddrOptions) (results []BatchResult[Addr], err error) {
+	return api.getAddrs(c, hashes, "/full", 50, opts)
+}
+
+//getAddrs batches an Address API endpoint.
+func (api *API) getAddrs(c context.Context, hashes []string, suffix string, maxLimit int, opts *AddrOptions) (results []BatchResult[Addr], err error) {
+	params, err := opts.params(maxLimit)
+	if err != nil {
+		return
+	}
+	results = batchGet(c, api, hashes, func(ids string) string { return "/addrs/" + ids + suffix }, params, addrKeys)
+	for i := range results {
+		opts.filter(&results[i].Value)
+	}
+	return
+}
+
+//addrKeys lists the ids an Addr answers: its
+//address, or the name of its wallet.
+func addrKeys(a Addr) []string {
+	return []string{a.Address, a.Wallet.Name, a.HDWallet.Name}
+}
+
+//batchGet fetches ids through the semicolon-batched endpoint
+//built by path, api.Batch.size() at a time, and returns their
+//results in order. key lists the ids a decoded value answers,
+//as BlockCypher doesn't promise to keep the batch's order.
+func batchGet[T any](c context.Context, api *API, ids []string, path func(ids string) string, params map[string]string, key func(T) []string) []BatchResult[T] {
+	results := make([]BatchResult[T], len(ids))
+	//each id is only asked once, even if repeated
+	index := make(map[string][]int)
+	var unique []string
+	for i, id := range ids {
+		if index[id] == nil {
+			unique = append(unique, id)
+		}
+		index[id] = append(index[id], i)
+	}
+	set := func(id string, v T, err error) {
+		for _, i := range index[id] {
+			results[i] = BatchResult[T]{Value: v, Err: err}
+		}
+	}
+	size := api.Batch.size()
+	var chunks [][]string
+	for start := 0; start < len(unique); start += size {
+		chunks = append(chunks, unique[start:min(start+size, len(unique))])
+	}
+	//a fixed set of workers takes the chunks in turn
+	queue := make(chan []string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for n := min(api.Batch.concurrency(), len(chunks)); n > 0; n-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range queue {
+				values, errs, err := batchChunk(c, api, chunk, path, params, key)
+				mu.Lock()
+				for i, id := range chunk {
+					if err != nil {
+						set(id, values[i], err)
+					} else {
+						set(id, values[i], errs[i])
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, chunk := range chunks {
+		select {
+		case queue <- chunk:
+		case <-c.Done():
+			mu.Lock()
+			for _, id := range chunk {
+				var zero T
+				set(id, zero, c.Err())
+			}
+			mu.Unlock()
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+//batchChunk fetches a single batch, returning a value
+//and an error for each of its ids.
+func batchChunk[T any](c context.Context, api *API, chunk []string, path func(ids string) string, params map[string]string, key func(T) []string) (values []T, errs []error, err error) {
+	values, errs = make([]T, len(chunk)), make([]error, len(chunk))
+	u, err := api.buildURL(path(strings.Join(chunk, ";")), params)
+	if err != nil {
+		return
+	}
+	req, err := newRequest("GET", u, nil)
+	if err != nil {
+		return
+	}
+	//each id counts as a call
+	req.weight = len(chunk)
+	data, err := api.fetch(c, req)
+	if err != nil {
+		return
+	}
+	//a batch of one answers with the bare object
+	var items []json.RawMessage
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &items)
+	} else {
+		items = []json.RawMessage{data}
+	}
+	if err != nil {
+		return
+	}
+	done := make([]bool, len(chunk))
+	//claim returns the position of the first pending id
+	//matching any of the given ones, or -1
+	claim := func(match func(id string) bool) int {
+		for i, id := range chunk {
+			if !done[i] && match(id) {
+				done[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+	endpoint := api.redactURL(u).Path
+	var unmatched []int
+	for n, raw := range items {
+		if apiErr := api.itemError(raw, endpoint); apiErr != nil {
+			i := claim(func(id string) bool { return containsWord(apiErr.Message, id) })
+			if i < 0 {
+				unmatched = append(unmatched, n)
+				continue
+			}
+			errs[i] = apiErr
+			continue
+		}
+		var v T
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return
+		}
+		keys := key(v)
+		i := claim(func(id string) bool {
+			for _, k := range keys {
+				if k != "" && strings.EqualFold(k, id) {
+					return true
+				}
+			}
+			return false
+		})
+		if i < 0 {
+			unmatched = append(unmatched, n)
+			continue
+		}
+		values[i] = v
+	}
+	//items that couldn't be matched by id keep the batch order
+	for _, n := range unmatched {
+		i := claim(func(string) bool { return true })
+		if i < 0 {
+			break
+		}
+		if apiErr := api.itemError(items[n], endpoint); apiErr != nil {
+			errs[i] = apiErr
+		} else if err = json.Unmarshal(items[n], &values[i]); err != nil {
+			return
+		}
+	}
+	for i := range chunk {
+		if !done[i] {
+			errs[i] = &APIError{StatusCode: http.StatusBadGateway, Message: "missing from the batch response", Method: "GET", Endpoint: endpoint}
+		}
+	}
+	return
+}
+
+//itemError returns the error of a failed batch item, an
+//{"error": ...} object, or nil if raw isn't one. Items
+//reported missing get a 404 status, others a 400.
+func (api *API) itemError(raw json.RawMessage, endpoint string) *APIError {
+	var failed struct {
+		Err string `json:"error"`
+	}
+	if json.Unmarshal(raw, &failed) != nil || failed.Err == "" {
+		return nil
+	}
+	apiErr := &APIError{StatusCode: http.StatusBadRequest, Message: api.redactString(failed.Err), Method: "GET", Endpoint: endpoint}
+	if strings.Contains(strings.ToLower(failed.Err), "not found") {
+		apiErr.StatusCode = http.StatusNotFound
+	}
+	return apiErr
+}
+
+//containsWord reports whether s holds id as a whole word, so
+//that the message about block "12" doesn't match id "1".
+func containsWord(s, id string) bool {
+	for i := strings.Index(s, id); i >= 0; {
+		end := i + len(id)
+		if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		next := strings.Index(s[i+1:], id)
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return false
+}
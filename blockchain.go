@@ -15,7 +15,7 @@ func (api *API) GetChain(c context.Context) (chain Blockchain, err error) {
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &chain)
+	err = api.getCached(c, u, &chain, false, api.keepMutable)
 	return
 }
 
@@ -37,7 +37,8 @@ func (api *API) GetBlock(c context.Context, height int, hash string, params map[
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &block)
+	err = api.getCached(c, u, &block, false, api.keepConfirmed(func() int { return block.Depth + 1 }))
+	block.NextTXs = stripToken(block.NextTXs)
 	return
 }
 
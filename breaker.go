@@ -0,0 +1,274 @@
+package gobcy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	//BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	//BreakerOpen fails every call without sending it.
+	BreakerOpen
+	//BreakerHalfOpen lets a few probe calls through
+	//to test whether BlockCypher has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+//CircuitBreaker stops sending requests while BlockCypher is
+//failing, so callers fail fast with a *BreakerError instead of
+//piling up on slow requests. It opens once the share of failed
+//calls within Window reaches ErrorRate, or after several timeouts
+//in a row; after Cooldown it half-opens and lets Probes calls
+//through, closing if they all succeed and reopening otherwise.
+//Only server errors (5xx), network errors and timeouts count as
+//failures, including those hit while reading the body, but not
+//calls cut short by the caller's own context. Zero fields take the defaults noted. A CircuitBreaker
+//can be shared by several APIs.
+type CircuitBreaker struct {
+	//ErrorRate is the share of failed calls that opens the
+	//breaker, once MinCalls were made in Window; 0.5 by default.
+	ErrorRate float64
+	//MinCalls is the number of calls in Window needed before
+	//ErrorRate applies; 20 by default.
+	MinCalls int
+	//Window is the period over which the error rate is
+	//measured; a minute by default.
+	Window time.Duration
+	//ConsecutiveTimeouts opens the breaker after that many
+	//timeouts in a row; 5 by default.
+	ConsecutiveTimeouts int
+	//Cooldown is how long the breaker stays open before
+	//half-opening; 30s by default.
+	Cooldown time.Duration
+	//Probes is how many calls must succeed while half-open
+	//to close the breaker; 1 by default.
+	Probes int
+	//OnStateChange, if set, is called on every change of
+	//state, e.g. to raise an alert. It must not block.
+	OnStateChange func(from, to BreakerState)
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	calls       int
+	failures    int
+	timeouts    int
+	openedAt    time.Time
+	probing     int
+	probed      int
+}
+
+//BreakerError is returned by calls rejected by an open
+//CircuitBreaker. It matches ErrCircuitOpen with errors.Is.
+type BreakerError struct {
+	//Wait is how long until the breaker half-opens.
+	Wait time.Duration
+}
+
+func (e *BreakerError) Error() string {
+	return "CircuitBreaker: open, retry in " + e.Wait.String()
+}
+
+//Is makes a BreakerError match ErrCircuitOpen.
+func (e *BreakerError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+//State returns the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+	return b.Cooldown
+}
+
+//allow reports whether a call may be sent, and whether it
+//is a probe of a half-open breaker. It is a no-op on a nil
+//breaker.
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	from := b.state
+	if b.state == BreakerOpen {
+		if wait := b.cooldown() - time.Since(b.openedAt); wait > 0 {
+			b.mu.Unlock()
+			return false, &BreakerError{Wait: wait}
+		}
+		b.state, b.probing, b.probed = BreakerHalfOpen, 0, 0
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probing+b.probed >= max(b.Probes, 1) {
+			b.mu.Unlock()
+			return false, &BreakerError{}
+		}
+		b.probing++
+		probe = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+	return
+}
+
+//record counts the outcome of a call let through by allow,
+//made with context c.
+func (b *CircuitBreaker) record(c context.Context, probe bool, err error) {
+	if b == nil {
+		return
+	}
+	failed, timeout := breakerFailure(c, err)
+	b.mu.Lock()
+	from := b.state
+	now := time.Now()
+	switch {
+	case probe && b.state == BreakerHalfOpen:
+		b.probing--
+		switch {
+		case failed:
+			b.open(now)
+		case err != nil && (c.Err() != nil || errors.Is(err, context.Canceled)):
+			//says nothing about the server
+		default:
+			if b.probed++; b.probed >= max(b.Probes, 1) {
+				b.reset(BreakerClosed, now)
+			}
+		}
+	case b.state == BreakerClosed:
+		window := b.Window
+		if window <= 0 {
+			window = time.Minute
+		}
+		if now.Sub(b.windowStart) > window {
+			b.windowStart, b.calls, b.failures = now, 0, 0
+		}
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if timeout {
+			b.timeouts++
+		} else {
+			b.timeouts = 0
+		}
+		rate, minCalls, timeouts := b.ErrorRate, b.MinCalls, b.ConsecutiveTimeouts
+		if rate <= 0 {
+			rate = 0.5
+		}
+		if minCalls <= 0 {
+			minCalls = 20
+		}
+		if timeouts <= 0 {
+			timeouts = 5
+		}
+		if b.calls >= minCalls && float64(b.failures) >= rate*float64(b.calls) || b.timeouts >= timeouts {
+			b.open(now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+}
+
+//open opens the breaker. b.mu must be held.
+func (b *CircuitBreaker) open(now time.Time) {
+	b.reset(BreakerOpen, now)
+	b.openedAt = now
+}
+
+//reset moves to state with fresh counters. b.mu must be held.
+func (b *CircuitBreaker) reset(state BreakerState, now time.Time) {
+	b.state = state
+	b.windowStart, b.calls, b.failures, b.timeouts = now, 0, 0, 0
+	b.probing, b.probed = 0, 0
+}
+
+//changed reports a change of state to OnStateChange.
+func (b *CircuitBreaker) changed(from, to BreakerState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
+
+//watch returns body, recording the call's outcome once it has
+//been read: a slow or broken body is a failure too, even if the
+//status was fine. It returns body as is on a nil breaker.
+func (b *CircuitBreaker) watch(c context.Context, probe bool, body io.ReadCloser) io.ReadCloser {
+	if b == nil {
+		return body
+	}
+	return &watchedBody{ReadCloser: body, done: func(err error) { b.record(c, probe, err) }}
+}
+
+//watchedBody calls done with the first read error, nil at
+//the end of the body or when it is closed early.
+type watchedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func(err error)
+}
+
+func (w *watchedBody) Read(p []byte) (n int, err error) {
+	n, err = w.ReadCloser.Read(p)
+	switch {
+	case err == io.EOF:
+		w.once.Do(func() { w.done(nil) })
+	case err != nil:
+		w.once.Do(func() { w.done(err) })
+	}
+	return
+}
+
+func (w *watchedBody) Close() error {
+	w.once.Do(func() { w.done(nil) })
+	return w.ReadCloser.Close()
+}
+
+//breakerFailure classifies the error of a call made with
+//context c: server and network errors are failures, and
+//timeouts are also counted on their own. Client errors (4xx)
+//are not failures, nor are calls cut short by c itself, like
+//a caller's tight deadline.
+func breakerFailure(c context.Context, err error) (failed, timeout bool) {
+	if err == nil || c.Err() != nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500, apiErr.StatusCode == 504
+	}
+	var netErr net.Error
+	timeout = errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+	return true, timeout
+}
@@ -0,0 +1,193 @@
+package gobcy
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//Store holds cached API responses. Implementations must be
+//safe for concurrent use; LRUStore is the in-memory one, and
+//disk or shared backends can be plugged in through this interface.
+type Store interface {
+	//Get returns the data stored under key, if it's
+	//present and hasn't expired.
+	Get(key string) (data []byte, ok bool)
+	//Set stores data under key for ttl, or with no
+	//expiry if ttl is zero.
+	Set(key string, data []byte, ttl time.Duration)
+}
+
+//Cache stores responses that can't change anymore, so they are
+//only fetched once: blocks and transactions buried under at least
+//MinConfirmations blocks. Mutable lookups like GetChain and
+//GetAddrBal are only cached for MutableTTL, if it's set. Entries
+//are keyed by host, coin/chain, path and parameters, never the token,
+//except for GetAddrBal, whose wallet names are per token; those
+//are also keyed by a hash of the tokens.
+//
+//Cached objects are served as they were when stored, so their
+//Confirmations and Depth don't grow anymore; fetch them again
+//without a Cache when you need current counts.
+type Cache struct {
+	Store Store
+	//MinConfirmations is how many confirmations a block or
+	//transaction needs before it is cached, 6 if zero.
+	MinConfirmations int
+	//MutableTTL is how long mutable responses may be served
+	//from the cache; zero disables caching them.
+	MutableTTL time.Duration
+}
+
+//NewCache returns a Cache backed by an LRUStore of size entries.
+func NewCache(size int) *Cache {
+	return &Cache{Store: NewLRUStore(size)}
+}
+
+//minConf returns the confirmations needed before caching.
+func (ca *Cache) minConf() int {
+	if ca.MinConfirmations <= 0 {
+		return 6
+	}
+	return ca.MinConfirmations
+}
+
+//cacheKey identifies a GET request without its token.
+func cacheKey(target *url.URL) string {
+	values := target.Query()
+	values.Del("token")
+	return target.Host + target.Path + "?" + values.Encode()
+}
+
+//tokenScope returns a hash of the API's tokens, to keep
+//per-token entries of APIs sharing a Cache apart.
+func (api *API) tokenScope() string {
+	sum := sha256.Sum256([]byte(strings.Join(api.secrets(), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+//getCached is getResponse for cacheable objects. After a
+//response is decoded, keep tells whether it may be cached
+//and for how long. perToken entries are only shared between
+//APIs with the same tokens.
+func (api *API) getCached(c context.Context, target *url.URL, decTarget interface{}, perToken bool, keep func() (ttl time.Duration, ok bool)) (err error) {
+	if api.Cache == nil || api.Cache.Store == nil {
+		return api.getResponse(c, target, decTarget)
+	}
+	key := cacheKey(target)
+	if perToken {
+		key = api.tokenScope() + " " + key
+	}
+	if data, ok := api.Cache.Store.Get(key); ok && json.Unmarshal(data, decTarget) == nil {
+		return
+	}
+	req, err := newRequest("GET", target, nil)
+	if err != nil {
+		return
+	}
+	data, err := api.fetch(c, req)
+	if err != nil {
+		return
+	}
+	if err = api.decode(req, data, decTarget); err != nil {
+		return
+	}
+	if ttl, ok := keep(); ok {
+		//next page links may echo the token back
+		for _, token := range api.secrets() {
+			data = bytes.ReplaceAll(data, []byte(token), []byte("REDACTED"))
+		}
+		api.Cache.Store.Set(key, data, ttl)
+	}
+	return
+}
+
+//keepConfirmed caches objects with enough confirmations forever.
+func (api *API) keepConfirmed(confirmations func() int) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		return 0, confirmations() >= api.Cache.minConf()
+	}
+}
+
+//keepMutable caches mutable objects for the cache's MutableTTL.
+func (api *API) keepMutable() (time.Duration, bool) {
+	return api.Cache.MutableTTL, api.Cache.MutableTTL > 0
+}
+
+//LRUStore is an in-memory Store that evicts the least
+//recently used entries beyond its size.
+type LRUStore struct {
+	size    int
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+//lruEntry is an entry of an LRUStore.
+type lruEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+//NewLRUStore returns an LRUStore holding up to size entries.
+func NewLRUStore(size int) *LRUStore {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUStore{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+//Get implements Store.
+func (s *LRUStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		return nil, false
+	}
+	s.order.MoveToFront(el)
+	return e.data, true
+}
+
+//Set implements Store.
+func (s *LRUStore) Set(key string, data []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &lruEntry{key: key, data: data}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	if el, ok := s.entries[key]; ok {
+		el.Value = e
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(e)
+	for s.order.Len() > s.size {
+		last := s.order.Back()
+		s.order.Remove(last)
+		delete(s.entries, last.Value.(*lruEntry).key)
+	}
+}
+
+//Len returns the number of entries in the store.
+func (s *LRUStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
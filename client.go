@@ -0,0 +1,83 @@
+package gobcy
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+//DefaultUserAgent is the User-Agent sent by a Client.
+const DefaultUserAgent = "gobcy/2"
+
+//ClientOptions tunes the connection pool of a Client.
+//Zero fields take the defaults noted.
+type ClientOptions struct {
+	//Timeout bounds each HTTP request, including reading
+	//the response body; 60s by default.
+	Timeout time.Duration
+	//MaxIdleConnsPerHost is how many keep-alive connections
+	//to BlockCypher are kept open; 64 by default.
+	MaxIdleConnsPerHost int
+	//UserAgent is sent with every request; DefaultUserAgent
+	//by default.
+	UserAgent string
+}
+
+//Client is a long-lived API that owns a pooled HTTP transport,
+//reusing connections (and HTTP/2 streams) across calls instead
+//of paying for new TLS handshakes. Responses are requested with
+//gzip compression and decompressed transparently.
+//
+//A Client is safe for concurrent use once configured: set any of
+//the embedded API's fields (Retry, Limiter, Cache, Middleware...)
+//before sharing it between goroutines, and Close it when done.
+type Client struct {
+	API
+	transport *http.Transport
+}
+
+//NewClient returns a Client for the given token/coin/chain,
+//which must be in the Networks registry. opts may be nil.
+func NewClient(token, coin, chain string, opts *ClientOptions) (*Client, error) {
+	if _, ok := LookupNetwork(coin, chain); !ok {
+		return nil, errors.New("NewClient: unknown coin/chain \"" + coin + "/" + chain + "\"")
+	}
+	var o ClientOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 60 * time.Second
+	}
+	if o.MaxIdleConnsPerHost <= 0 {
+		o.MaxIdleConnsPerHost = 64
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	tr := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          o.MaxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   o.MaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	cl := &Client{
+		API:       NewAPI(token, coin, chain, &http.Client{Transport: tr, Timeout: o.Timeout}),
+		transport: tr,
+	}
+	cl.UserAgent = o.UserAgent
+	return cl, nil
+}
+
+//Close releases the Client's idle connections.
+func (cl *Client) Close() {
+	cl.transport.CloseIdleConnections()
+}
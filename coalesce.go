@@ -0,0 +1,85 @@
+package gobcy
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+//Coalescer merges identical GET requests made at the same time,
+//e.g. by many goroutines looking up the same transaction, into a
+//single request whose response is shared by all callers. Requests
+//are only merged if their coin/chain, path, parameters and token
+//all match; each caller still decodes its own copy of the result.
+//The zero value is ready to use, and may be shared by several APIs.
+type Coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+//flight is a request in progress, waited on by its duplicates.
+type flight struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+//do returns the result of fn, or of an identical call
+//already in flight under the same key.
+func (co *Coalescer) do(c context.Context, key string, fn func() ([]byte, error)) (data []byte, err error) {
+	co.mu.Lock()
+	if f, ok := co.flights[key]; ok {
+		co.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-c.Done():
+			return nil, c.Err()
+		}
+		//the leader gave up on its own context,
+		//which says nothing about ours
+		if c.Err() == nil && (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) {
+			return co.do(c, key, fn)
+		}
+		return f.data, f.err
+	}
+	if co.flights == nil {
+		co.flights = make(map[string]*flight)
+	}
+	f := &flight{done: make(chan struct{})}
+	co.flights[key] = f
+	co.mu.Unlock()
+
+	f.data, f.err = fn()
+	co.mu.Lock()
+	delete(co.flights, key)
+	co.mu.Unlock()
+	close(f.done)
+	return f.data, f.err
+}
+
+//fetch sends req and returns its response body, coalescing
+//it with identical GETs in flight if api.Coalesce is set.
+func (api *API) fetch(c context.Context, req *request) (data []byte, err error) {
+	read := func() (data []byte, err error) {
+		resp, err := api.do(c, req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	}
+	if api.Coalesce == nil || req.method != "GET" {
+		return read()
+	}
+	token := api.Token
+	if req.token != "" {
+		token = req.token
+	} else if api.Tokens != nil {
+		//pooled tokens see the same data, except
+		//for the pinned calls keyed above
+		token = "pool"
+	}
+	return api.Coalesce.do(c, token+" "+req.target.String(), read)
+}
@@ -0,0 +1,149 @@
+package gobcy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Sentinel errors that an *APIError can be matched against
+//with errors.Is, e.g.
+//	if errors.Is(err, gobcy.ErrNotFound) { ... }
+var (
+	//ErrNotFound matches HTTP 404 responses.
+	ErrNotFound = errors.New("not found")
+	//ErrRateLimited matches HTTP 429 responses.
+	ErrRateLimited = errors.New("rate limited")
+	//ErrUnauthorized matches HTTP 401 and 403 responses,
+	//usually caused by a missing or invalid token.
+	ErrUnauthorized = errors.New("unauthorized")
+	//ErrInsufficientFunds matches responses rejecting a
+	//transaction because its inputs can't cover the outputs
+	//and fees.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	//ErrCircuitOpen matches calls rejected by an open
+	//CircuitBreaker without being sent.
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+//APIError is returned whenever BlockCypher answers with an
+//unexpected HTTP status. It carries the status, the top-level
+//"error" message, the individual "errors" entries and the
+//request that caused it.
+type APIError struct {
+	StatusCode int
+	//Message is the top-level "error" field of the response.
+	Message string
+	//Messages holds the entries of the "errors" array.
+	Messages []string
+	//Method and Endpoint identify the failed request;
+	//Endpoint is the URL path, without query parameters.
+	Method   string
+	Endpoint string
+	//RetryAfter is the delay requested by the server through
+	//the Retry-After header, or the "retry_after" field (in
+	//seconds) of a 429 body, or zero if there was none.
+	RetryAfter time.Duration
+}
+
+//Error formats the status and messages like
+//	HTTP 404 Not Found, Message(s): Transaction not found (GET /v1/btc/main/txs/...)
+func (e *APIError) Error() string {
+	msg := "HTTP " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	if all := e.allMessages(); len(all) > 0 {
+		msg += ", Message(s): " + strings.Join(all, ", ")
+	}
+	if e.Method != "" || e.Endpoint != "" {
+		msg += " (" + strings.TrimSpace(e.Method+" "+e.Endpoint) + ")"
+	}
+	return msg
+}
+
+//Is reports whether e matches one of the package's sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrInsufficientFunds:
+		for _, m := range e.allMessages() {
+			m = strings.ToLower(m)
+			if strings.Contains(m, "not enough funds") || strings.Contains(m, "insufficient") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//allMessages returns the top-level message followed
+//by the individual error entries.
+func (e *APIError) allMessages() (all []string) {
+	if e.Message != "" {
+		all = append(all, e.Message)
+	}
+	return append(all, e.Messages...)
+}
+
+//respErrorMaker builds an *APIError out of a response
+//with an unexpected status code, collecting the messages
+//from its JSON body when there are any. endpoint is the
+//request's path, already scrubbed of the token; the
+//messages are scrubbed with redact, as the server may
+//echo the token in them.
+func respErrorMaker(resp *http.Response, endpoint string, redact func(string) string) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+	}
+	var msg struct {
+		Err    string `json:"error"`
+		Errors []struct {
+			Err string `json:"error"`
+		} `json:"errors"`
+		RetryAfter float64 `json:"retry_after"`
+	}
+	//A body that isn't JSON (e.g. a proxy's error page)
+	//still leaves us with the status to report.
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(body, &msg) == nil {
+		apiErr.Message = redact(msg.Err)
+		for _, v := range msg.Errors {
+			if v.Err != "" {
+				apiErr.Messages = append(apiErr.Messages, redact(v.Err))
+			}
+		}
+		if apiErr.RetryAfter == 0 && resp.StatusCode == http.StatusTooManyRequests && msg.RetryAfter > 0 {
+			apiErr.RetryAfter = time.Duration(msg.RetryAfter * float64(time.Second))
+		}
+	}
+	return apiErr
+}
+
+//parseRetryAfter reads a Retry-After header given either
+//in seconds or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
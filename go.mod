@@ -1,15 +1,20 @@
 module github.com/ThePiachu/gobcy/v2
 
-go 1.22.8
+go 1.23
 
 require (
 	github.com/btcsuite/btcd/btcec/v2 v2.3.2
+	github.com/btcsuite/btcd/btcutil v1.1.5
+	golang.org/x/crypto v0.28.0
 	golang.org/x/net v0.30.0
 	google.golang.org/appengine/v2 v2.0.6
 )
 
 require (
+	github.com/btcsuite/btcd v0.23.5-0.20231215221805-96c9fd8078fd // indirect
+	github.com/btcsuite/btcd/chaincfg/chainhash v1.1.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
+	golang.org/x/sys v0.26.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 )
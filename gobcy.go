@@ -17,13 +17,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
 
 	"golang.org/x/net/context"
-	"google.golang.org/appengine/v2/urlfetch"
 )
 
-const baseURL = "https://api.blockcypher.com/v1/"
+//DefaultBaseURL is the BlockCypher API root used when
+//API.BaseURL is empty.
+const DefaultBaseURL = "https://api.blockcypher.com/v1/"
 
 //API stores your BlockCypher Token, and the coin/chain
 //you're querying. Coins can be "btc","bcy","ltc", and "doge".
@@ -32,159 +33,334 @@ const baseURL = "https://api.blockcypher.com/v1/"
 //All your credentials are stored within an API struct, as are
 //many of the API methods.
 //You can allocate an API struct like so:
-//	bc = gobcy.API{"your-api-token","btc","main"}
+//	bc = gobcy.API{Token: "your-api-token", Coin: "btc", Chain: "main"}
 //Then query as you like:
 //	chain = bc.GetChain()
+//By default requests are sent through App Engine's urlfetch
+//service; set HTTPClient (or use NewAPI) to run anywhere else.
 type API struct {
 	Token, Coin, Chain string
+	//Tokens, if set, spreads calls over a pool of tokens
+	//instead of using Token.
+	Tokens *TokenPool
+	//HTTPClient sends every request made through this API.
+	//If nil, requests go through URLFetchTransport using
+	//the context passed to each call.
+	HTTPClient *http.Client
+	//BaseURL is the API root requests are resolved against,
+	//including any path prefix, e.g. "http://localhost:8080/v1/"
+	//for a local stand-in server or "https://proxy/bcy/v1/" for
+	//a caching proxy. If empty, DefaultBaseURL is used.
+	BaseURL string
+	//Retry, if set, repeats calls that failed because of
+	//rate limiting or transient server and network errors.
+	Retry *RetryPolicy
+	//Limiter, if set, holds calls back to stay within the
+	//token's rate limits before the server rejects them.
+	Limiter *RateLimiter
+	//Breaker, if set, fails calls fast while BlockCypher
+	//keeps failing.
+	Breaker *CircuitBreaker
+	//Cache, if set, serves settled blocks and transactions
+	//(and, briefly, mutable lookups) without a request.
+	Cache *Cache
+	//Coalesce, if set, merges identical GETs in flight
+	//at the same time into a single request.
+	Coalesce *Coalescer
+	//Batch tunes batch calls like GetTXs; nil uses
+	//full-sized batches, 4 at a time.
+	Batch *BatchPolicy
+	//Middleware wraps every outgoing request, the first
+	//one being the outermost.
+	Middleware []Middleware
+	//TokenHeader, if set, names an HTTP header that carries
+	//the token instead of the "token" query parameter. Only
+	//use it with servers or proxies that accept it.
+	TokenHeader string
+	//Strict makes calls check responses against the types
+	//they decode into, returning a *SchemaError for unknown
+	//fields and type mismatches.
+	Strict bool
+	//UserAgent, if set, is sent as the User-Agent header.
+	UserAgent string
 }
 
-//getResponse is a boilerplate for HTTP GET responses.
-func getResponse(c context.Context, target *url.URL, decTarget interface{}) (err error) {
-	tr := urlfetch.Transport{Context: c}
-	client := http.Client{Transport: &tr}
-	resp, err := client.Get(target.String())
-	if err != nil {
-		return
+//NewAPI returns an API for the given token/coin/chain that
+//sends its requests through client. A nil client falls back
+//to http.DefaultClient. Call Validate, or use NewCheckedAPI,
+//to check coin/chain.
+func NewAPI(token, coin, chain string, client *http.Client) API {
+	if client == nil {
+		client = http.DefaultClient
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
-		return
+	return API{Token: token, Coin: coin, Chain: chain, HTTPClient: client}
+}
+
+//NewCheckedAPI is NewAPI, but fails right away if coin/chain
+//isn't a known network, instead of on the first call.
+func NewCheckedAPI(token, coin, chain string, client *http.Client) (api API, err error) {
+	api = NewAPI(token, coin, chain, client)
+	if err = api.Validate(); err != nil {
+		return API{}, err
 	}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(decTarget)
 	return
 }
 
-//postResponse is a boilerplate for HTTP POST responses.
-func postResponse(c context.Context, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
-	var data bytes.Buffer
-	enc := json.NewEncoder(&data)
-	if err = enc.Encode(encTarget); err != nil {
-		return
+//NewAPIWithTransport returns an API for the given token/coin/chain
+//that sends its requests through rt, e.g. an *http.Transport or
+//URLFetchTransport on App Engine.
+func NewAPIWithTransport(token, coin, chain string, rt http.RoundTripper) API {
+	return NewAPI(token, coin, chain, &http.Client{Transport: rt})
+}
+
+//urlfetchClient is used by APIs without an HTTPClient.
+var urlfetchClient = &http.Client{Transport: URLFetchTransport{}}
+
+//httpClient returns the client used to send requests.
+func (api *API) httpClient() *http.Client {
+	if api.HTTPClient != nil {
+		return api.HTTPClient
+	}
+	return urlfetchClient
+}
+
+//request describes a single API call, independent
+//of the number of attempts needed to complete it.
+type request struct {
+	method string
+	target *url.URL
+	body   []byte
+	//idempotent calls may be repeated after transient server
+	//or network errors; others are only retried after a 429.
+	idempotent bool
+	//unmetered calls don't draw from the rate limiter,
+	//like the usage check used to seed it.
+	unmetered bool
+	//token is the pool token used by the current attempt;
+	//pinned requests keep it instead of rotating.
+	token  string
+	pinned bool
+	//weight is the number of calls BlockCypher counts the
+	//request as, like the ids of a batch; 0 means 1.
+	weight int
+}
+
+//newRequest returns a request, JSON-encoding encTarget
+//as its body for POST and PUT calls.
+func newRequest(method string, target *url.URL, encTarget interface{}) (req *request, err error) {
+	req = &request{method: method, target: target, idempotent: method != "POST"}
+	if method == "POST" || method == "PUT" {
+		req.body, err = json.Marshal(encTarget)
+	}
+	return
+}
+
+//okStatus reports whether code is a successful
+//response to the given method.
+func okStatus(method string, code int) bool {
+	switch method {
+	case "GET":
+		return code == http.StatusOK
+	case "POST":
+		return code == http.StatusOK || code == http.StatusCreated
+	default:
+		return code == http.StatusOK || code == http.StatusNoContent
+	}
+}
+
+//do sends req, retrying according to api.Retry, and returns
+//the successful response. The caller must close its body.
+func (api *API) do(c context.Context, req *request) (resp *http.Response, err error) {
+	//failovers counts the attempts that failed over to another
+	//token, round those since the last backoff
+	failovers, round := 0, 0
+	for attempt := 1; ; attempt++ {
+		if api.Tokens != nil && !req.pinned {
+			req.token = api.Tokens.pick(req.budgets(), req.calls(), req.target.Path)
+		}
+		if api.Limiter != nil && !req.unmetered {
+			if err = api.Limiter.WaitN(c, req.calls(), req.budgets()...); err != nil {
+				return
+			}
+		}
+		var probe bool
+		if probe, err = api.Breaker.allow(); err != nil {
+			return
+		}
+		resp, err = api.attempt(c, req, attempt)
+		if err == nil {
+			//the breaker hears of it once the body is read
+			resp.Body = api.Breaker.watch(c, probe, resp.Body)
+			return
+		}
+		api.Breaker.record(c, probe, err)
+		if api.Tokens != nil && !req.pinned && api.Tokens.bench(req.token, err) && round < api.Tokens.size() {
+			//fail over to another token without backing off,
+			//once per token before the next backoff
+			failovers++
+			round++
+			continue
+		}
+		delay, retry := api.Retry.backoff(c, attempt-failovers, err, req.idempotent)
+		if !retry {
+			return
+		}
+		round = 0
+		if sleep(c, delay) != nil {
+			return
+		}
+	}
+}
+
+//send performs a single HTTP attempt of req, adding the
+//token (or the request's pool token) to it. Errors are scrubbed of the token.
+func (api *API) send(c context.Context, req *request) (resp *http.Response, err error) {
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	token := api.Token
+	if req.token != "" {
+		token = req.token
 	}
-	tr := urlfetch.Transport{Context: c}
-	client := http.Client{Transport: &tr}
-	resp, err := client.Post(target.String(), "application/json", &data)
+	target := *req.target
+	if token != "" && api.TokenHeader == "" {
+		values := target.Query()
+		values.Set("token", token)
+		target.RawQuery = values.Encode()
+	}
+	hreq, err := http.NewRequestWithContext(c, req.method, target.String(), body)
 	if err != nil {
-		return
+		return nil, api.redactError(err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
-		return
+	if req.body != nil {
+		hreq.Header.Set("Content-Type", "application/json")
 	}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(decTarget)
-	return
+	if token != "" && api.TokenHeader != "" {
+		hreq.Header.Set(api.TokenHeader, token)
+	}
+	if api.UserAgent != "" {
+		hreq.Header.Set("User-Agent", api.UserAgent)
+	}
+	resp, err = api.httpClient().Do(hreq)
+	return resp, api.redactError(err)
 }
 
-//putResponse is a boilerplate for HTTP PUT responses.
-func putResponse(c context.Context, target *url.URL, encTarget interface{}) (err error) {
-	var data bytes.Buffer
-	enc := json.NewEncoder(&data)
-	if err = enc.Encode(encTarget); err != nil {
+//getResponse is a boilerplate for HTTP GET responses.
+func (api *API) getResponse(c context.Context, target *url.URL, decTarget interface{}) (err error) {
+	req, err := newRequest("GET", target, nil)
+	if err != nil {
 		return
 	}
-	req, err := http.NewRequest("PUT", target.String(), &data)
+	return api.decodeResponse(c, req, decTarget)
+}
+
+//postResponse is a boilerplate for HTTP POST responses.
+func (api *API) postResponse(c context.Context, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
+	req, err := newRequest("POST", target, encTarget)
 	if err != nil {
 		return
 	}
-	tr := urlfetch.Transport{Context: c}
-	client := http.Client{Transport: &tr}
-	resp, err := client.Do(req)
+	return api.decodeResponse(c, req, decTarget)
+}
+
+//idempotentPostResponse is a boilerplate for HTTP POST responses
+//that are safe to repeat, like building or pushing a transaction
+//whose hash doesn't change between attempts.
+func (api *API) idempotentPostResponse(c context.Context, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
+	req, err := newRequest("POST", target, encTarget)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
-	}
-	return
+	req.idempotent = true
+	return api.decodeResponse(c, req, decTarget)
 }
 
-//deleteResponse is a boilerplate for HTTP DELETE responses.
-func deleteResponse(c context.Context, target *url.URL) (err error) {
-	req, err := http.NewRequest("DELETE", target.String(), nil)
+//putResponse is a boilerplate for HTTP PUT responses.
+func (api *API) putResponse(c context.Context, target *url.URL, encTarget interface{}) (err error) {
+	req, err := newRequest("PUT", target, encTarget)
 	if err != nil {
 		return
 	}
-	tr := urlfetch.Transport{Context: c}
-	client := http.Client{Transport: &tr}
-	resp, err := client.Do(req)
+	return api.decodeResponse(c, req, nil)
+}
+
+//deleteResponse is a boilerplate for HTTP DELETE responses.
+func (api *API) deleteResponse(c context.Context, target *url.URL) (err error) {
+	req, err := newRequest("DELETE", target, nil)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
+	return api.decodeResponse(c, req, nil)
+}
+
+//decodeResponse sends req and decodes its JSON response into
+//decTarget, unless decTarget is nil.
+func (api *API) decodeResponse(c context.Context, req *request, decTarget interface{}) (err error) {
+	data, err := api.fetch(c, req)
+	if err != nil || decTarget == nil {
+		return
 	}
+	err = api.decode(req, data, decTarget)
 	return
 }
 
-//respErrorMaker checks error messages/if they are multiple errors
-//serializes them into a single error message
-func respErrorMaker(statusCode int, body io.Reader) (err error) {
-	status := "HTTP " + strconv.Itoa(statusCode) + " " + http.StatusText(statusCode)
-	if statusCode == 429 {
-		err = errors.New(status)
-		return
+//resolve parses the API root and appends the given path to it.
+func (api *API) resolve(path string) (target *url.URL, err error) {
+	base := api.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
 	}
-	type errorJSON struct {
-		Err    string `json:"error"`
-		Errors []struct {
-			Err string `json:"error"`
-		} `json:"errors"`
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
 	}
-	var msg errorJSON
-	dec := json.NewDecoder(body)
-	err = dec.Decode(&msg)
+	target, err = url.Parse(base + path)
 	if err != nil {
-		return err
-	}
-	var errtxt string
-	errtxt += msg.Err
-	for i, v := range msg.Errors {
-		if i == len(msg.Errors)-1 {
-			errtxt += v.Err
-		} else {
-			errtxt += v.Err + ", "
-		}
+		return
 	}
-	if errtxt == "" {
-		err = errors.New(status)
-	} else {
-		err = errors.New(status + ", Message(s): " + errtxt)
+	if target.Scheme == "" || target.Host == "" {
+		err = errors.New("API.BaseURL must be an absolute URL, got \"" + api.BaseURL + "\"")
 	}
 	return
 }
 
 //constructs BlockCypher URLs with parameters for requests
 func (api *API) buildURL(u string, params map[string]string) (target *url.URL, err error) {
-	target, err = url.Parse(baseURL + api.Coin + "/" + api.Chain + u)
+	target, err = api.resolve(api.Coin + "/" + api.Chain + u)
 	if err != nil {
 		return
 	}
 	values := target.Query()
-	//Set parameters
+	//Set parameters; the token is only added when
+	//sending, so built URLs never carry it
 	for k, v := range params {
-		values.Set(k, v)
-	}
-	//add token to url, if present
-	if api.Token != "" {
-		values.Set("token", api.Token)
+		if k != "token" {
+			values.Set(k, v)
+		}
 	}
 	target.RawQuery = values.Encode()
 	return
 }
 
-// CheckUsage checks token usage
+// CheckUsage checks token usage: that of api.Token, or of
+// a token of api.Tokens if there is no Token. TokenPool.Refresh
+// checks every token of a pool.
 func (api *API) CheckUsage(c context.Context) (usage TokenUsage, err error) {
-	u, err := url.Parse(baseURL + "tokens/" + api.Token)
+	token := api.Token
+	if token == "" && api.Tokens != nil {
+		token = api.Tokens.pick(nil, 0, "")
+	}
+	u, err := api.resolve("tokens/" + token)
+	if err != nil {
+		return
+	}
+	req, err := newRequest("GET", u, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &usage)
+	//the usage asked for is that of the token sent
+	req.token, req.pinned = token, true
+	req.unmetered = true
+	err = api.decodeResponse(c, req, &usage)
 	return
 }
@@ -0,0 +1,252 @@
+package gobcytest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//getChain answers GET /.
+func (s *Server) getChain(c *call) (interface{}, *apiError) {
+	tip := s.tip()
+	unconfirmed := 0
+	for _, rec := range s.txs {
+		if rec.height < 0 {
+			unconfirmed++
+		}
+	}
+	return gobcy.Blockchain{
+		Name:             chainName(s.Coin, s.Chain),
+		Height:           tip.height,
+		Hash:             tip.hash,
+		Time:             tip.time,
+		PrevHash:         tip.prev,
+		PeerCount:        250,
+		HighFee:          30000,
+		MediumFee:        20000,
+		LowFee:           10000,
+		UnconfirmedCount: unconfirmed,
+	}, nil
+}
+
+//getBlocks answers GET /blocks/{height or hash}, including
+//semicolon-separated batches.
+func (s *Server) getBlocks(c *call) (interface{}, *apiError) {
+	txstart := c.intParam("txstart", 0)
+	limit := c.intParam("limit", 20)
+	if limit < 1 || limit > 500 {
+		return nil, errorf(http.StatusBadRequest, "limit must be between 1 and 500")
+	}
+	one := func(id string) (interface{}, *apiError) {
+		for _, b := range s.blocks {
+			if b.hash == id || strconv.Itoa(b.height) == id {
+				return s.renderBlock(b, txstart, limit, s.URL+"/v1/"+s.Coin+"/"+s.Chain), nil
+			}
+		}
+		return nil, errorf(http.StatusNotFound, "Block "+id+" not found.")
+	}
+	return batch(c.parts[1], one)
+}
+
+//batch runs one for every semicolon-separated id. A single id
+//answers with its own value or error; several ids answer with
+//an array in which failed items become {"error": ...} objects.
+func batch(ids string, one func(id string) (interface{}, *apiError)) (interface{}, *apiError) {
+	list := strings.Split(ids, ";")
+	if len(list) == 1 {
+		return one(list[0])
+	}
+	if len(list) > 100 {
+		return nil, errorf(http.StatusBadRequest, "Batch requests are limited to 100 elements.")
+	}
+	out := make([]interface{}, len(list))
+	for i, id := range list {
+		v, err := one(id)
+		if err != nil {
+			out[i] = map[string]string{"error": err.msg}
+		} else {
+			out[i] = addressable(v)
+		}
+	}
+	return out, nil
+}
+
+//genAddr answers POST /addrs, generating a key pair or,
+//given pubkeys and a multisig script type, a P2SH address.
+func (s *Server) genAddr(c *call) (interface{}, *apiError) {
+	var req gobcy.AddrKeychain
+	if c.r.ContentLength != 0 {
+		if err := c.decode(&req); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.PubKeys) == 0 {
+		_, keys := s.newKey()
+		return keys, nil
+	}
+	n, m, ok := parseMultisig(req.ScriptType)
+	if !ok || m != len(req.PubKeys) || n > m {
+		return nil, errorf(http.StatusBadRequest, "Invalid script_type "+req.ScriptType+" for "+strconv.Itoa(len(req.PubKeys))+" pubkeys.")
+	}
+	script := strconv.Itoa(n)
+	for _, v := range req.PubKeys {
+		script += v
+	}
+	req.Address = s.scriptAddr([]byte(script + "multisig"))
+	return req, nil
+}
+
+//parseMultisig reads a "multisig-n-of-m" script type.
+func parseMultisig(scriptType string) (n, m int, ok bool) {
+	var err error
+	parts := strings.Split(scriptType, "-")
+	if len(parts) != 4 || parts[0] != "multisig" || parts[2] != "of" {
+		return
+	}
+	if n, err = strconv.Atoi(parts[1]); err != nil || n < 1 {
+		return
+	}
+	if m, err = strconv.Atoi(parts[3]); err != nil || m < 1 {
+		return
+	}
+	return n, m, true
+}
+
+//faucet answers POST /faucet.
+func (s *Server) faucet(c *call) (interface{}, *apiError) {
+	var req struct {
+		Address string `json:"address"`
+		Amount  int64  `json:"amount"`
+	}
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	if !s.validAddr(req.Address) {
+		return nil, errorf(http.StatusBadRequest, "Invalid address: "+req.Address)
+	}
+	if req.Amount <= 0 || req.Amount > 1e7 {
+		return nil, errorf(http.StatusBadRequest, "Amount must be between 1 and 10000000 satoshis.")
+	}
+	return map[string]string{"tx_ref": s.fundLocked(req.Address, req.Amount)}, nil
+}
+
+//resolveAddrs turns an address or wallet name into the
+//addresses it stands for, filling in the wallet fields of addr.
+func (s *Server) resolveAddrs(token, name string, addr *gobcy.Addr) ([]string, *apiError) {
+	if w := s.wallets[token][name]; w != nil {
+		addr.Wallet = gobcy.Wallet{Name: name, Addresses: w.addresses}
+		return w.addresses, nil
+	}
+	if w := s.hd[token][name]; w != nil {
+		addr.HDWallet = s.renderHDWallet(w)
+		return w.addresses(), nil
+	}
+	if !s.validAddr(name) {
+		return nil, errorf(http.StatusBadRequest, "Invalid address: "+name+".")
+	}
+	addr.Address = name
+	return []string{name}, nil
+}
+
+//getAddrs answers the balance, txrefs ("") and full address
+//endpoints, including semicolon-separated batches.
+func (s *Server) getAddrs(kind string) handler {
+	return func(c *call) (interface{}, *apiError) {
+		maxLimit, defLimit := 2000, 50
+		if kind == "full" {
+			maxLimit, defLimit = 50, 10
+		}
+		limit := c.intParam("limit", defLimit)
+		if limit < 1 || limit > maxLimit {
+			return nil, errorf(http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+		}
+		one := func(name string) (interface{}, *apiError) {
+			var addr gobcy.Addr
+			addrs, err := s.resolveAddrs(c.token, name, &addr)
+			if err != nil {
+				return nil, err
+			}
+			v := s.viewAddrs(addrs, c.intParam("confirmations", 0))
+			v.addr.Address, v.addr.Wallet, v.addr.HDWallet = addr.Address, addr.Wallet, addr.HDWallet
+			if c.boolParam("omitWalletAddresses") {
+				v.addr.Wallet.Addresses = nil
+			}
+			switch kind {
+			case "":
+				s.pageRefs(c, &v, limit)
+			case "full":
+				s.pageTXs(c, &v, limit)
+			}
+			return v.addr, nil
+		}
+		return batch(c.parts[1], one)
+	}
+}
+
+//inRange reports whether a block height passes the
+//"before" and "after" filters of a call.
+func inRange(c *call, height int) bool {
+	if before := c.intParam("before", 0); before > 0 && height >= before {
+		return false
+	}
+	if after := c.intParam("after", 0); after > 0 && height <= after {
+		return false
+	}
+	return true
+}
+
+//pageRefs fills in the txrefs of an address view.
+func (s *Server) pageRefs(c *call, v *addrView, limit int) {
+	keep := func(r gobcy.TXRef) bool {
+		if c.boolParam("unspentOnly") && (r.TXOutputN < 0 || r.Spent) {
+			return false
+		}
+		return true
+	}
+	finish := func(r gobcy.TXRef) gobcy.TXRef {
+		if !c.boolParam("includeScript") {
+			r.Script = ""
+		}
+		if c.boolParam("includeConfidence") && r.BlockHeight < 0 {
+			r.Confidence = 0.99
+		}
+		return r
+	}
+	if c.param("before") == "" {
+		for _, r := range v.unconfed {
+			if keep(r) {
+				v.addr.UnconfirmedTXRefs = append(v.addr.UnconfirmedTXRefs, finish(r))
+			}
+		}
+	}
+	for _, r := range v.confirmed {
+		if !keep(r) || !inRange(c, r.BlockHeight) {
+			continue
+		}
+		if len(v.addr.TXRefs) == limit {
+			v.addr.HasMore = true
+			break
+		}
+		v.addr.TXRefs = append(v.addr.TXRefs, finish(r))
+	}
+}
+
+//pageTXs fills in the full transactions of an address view.
+func (s *Server) pageTXs(c *call, v *addrView, limit int) {
+	for _, h := range v.txs {
+		rec := s.txs[h]
+		if rec.height < 0 && c.param("before") != "" {
+			continue
+		}
+		if rec.height >= 0 && !inRange(c, rec.height) {
+			continue
+		}
+		if len(v.addr.TXs) == limit {
+			v.addr.HasMore = true
+			break
+		}
+		v.addr.TXs = append(v.addr.TXs, s.renderTX(rec))
+	}
+}
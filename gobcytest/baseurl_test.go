@@ -0,0 +1,37 @@
+package gobcytest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBaseURL(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	//a proxy serving the API under its own path prefix
+	srv.Config.Handler = http.StripPrefix("/proxy/bcy", srv.Config.Handler)
+	for _, base := range []string{srv.URL + "/proxy/bcy/v1/", srv.URL + "/proxy/bcy/v1"} {
+		bcy.BaseURL = base
+		if _, err := bcy.GetChain(c); err != nil {
+			t.Errorf("GetChain under %v error encountered: %v", base, err)
+		}
+		if _, err := bcy.CheckUsage(c); err != nil {
+			t.Errorf("CheckUsage under %v error encountered: %v", base, err)
+		}
+	}
+	bcy.BaseURL = srv.URL + "/v1/"
+	if _, err := bcy.GetChain(c); err == nil {
+		t.Error("Expected error without the proxy's prefix, did not receive one")
+	}
+	for _, base := range []string{"proxy/bcy/v1/", "/v1/"} {
+		bcy.BaseURL = base
+		if _, err := bcy.GetChain(c); err == nil || !strings.Contains(err.Error(), "must be an absolute URL") {
+			t.Errorf("Expected an absolute URL error for %v, got %v", base, err)
+		}
+		if _, err := bcy.CheckUsage(c); err == nil || !strings.Contains(err.Error(), "must be an absolute URL") {
+			t.Errorf("Expected an absolute URL error from CheckUsage for %v, got %v", base, err)
+		}
+	}
+}
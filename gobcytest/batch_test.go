@@ -0,0 +1,81 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestBatchPrefixIDs(t *testing.T) {
+	c := context.Background()
+	//the error about block 12 comes first, out of order
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"error": "Block 12 not found."}, {"hash": "0000aa", "height": 1}, {"error": "Block \"100\" not found."}]`))
+	}))
+	defer srv.Close()
+	bcy := gobcy.NewAPI("test-token", "btc", "main", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	blocks, err := bcy.GetBlocks(c, []string{"1", "10", "12", "100"}, nil)
+	if err != nil {
+		t.Fatal("GetBlocks error encountered: ", err)
+	}
+	if blocks[0].Err != nil || blocks[0].Value.Height != 1 {
+		t.Errorf("Expected block 1, got %+v", blocks[0])
+	}
+	if !errors.Is(blocks[2].Err, gobcy.ErrNotFound) || !errors.Is(blocks[3].Err, gobcy.ErrNotFound) {
+		t.Errorf("Expected blocks 12 and 100 to be missing, got %+v, %+v", blocks[2], blocks[3])
+	}
+	if blocks[1].Err == nil || errors.Is(blocks[1].Err, gobcy.ErrNotFound) {
+		t.Errorf("Expected block 10 to be missing from the response, got %+v", blocks[1])
+	}
+}
+
+func TestBatchWorkers(t *testing.T) {
+	c := context.Background()
+	var inFlight, maxInFlight atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+		}
+		<-release
+		w.Write([]byte(`{"error": "Block not found."}`))
+	}))
+	defer srv.Close()
+	bcy := gobcy.NewAPI("test-token", "btc", "main", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	bcy.Batch = &gobcy.BatchPolicy{Size: 1, Concurrency: 2}
+	var ids []string
+	for i := 0; i < 200; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	before := runtime.NumGoroutine()
+	done := make(chan []gobcy.BatchResult[gobcy.Block])
+	go func() {
+		blocks, _ := bcy.GetBlocks(c, ids, nil)
+		done <- blocks
+	}()
+	for deadline := time.Now().Add(5 * time.Second); inFlight.Load() < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine() - before; n > 50 {
+		t.Errorf("Expected a goroutine per worker, not per chunk, got %v more", n)
+	}
+	close(release)
+	blocks := <-done
+	if len(blocks) != len(ids) || blocks[199].Err == nil {
+		t.Errorf("Unexpected results %+v", blocks[199])
+	}
+	if n := maxInFlight.Load(); n != 2 {
+		t.Errorf("Expected 2 requests in flight, got %v", n)
+	}
+}
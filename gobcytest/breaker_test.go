@@ -0,0 +1,97 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestBreaker(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	var changes []string
+	bcy.Breaker = &gobcy.CircuitBreaker{
+		MinCalls: 4,
+		Cooldown: 50 * time.Millisecond,
+		OnStateChange: func(from, to gobcy.BreakerState) {
+			changes = append(changes, from.String()+">"+to.String())
+		},
+	}
+	srv.FailNext(503, 3)
+	for i := 0; i < 4; i++ {
+		bcy.GetChain(c)
+	}
+	if bcy.Breaker.State() != gobcy.BreakerOpen {
+		t.Fatal("Expected the breaker to open after 3 of 4 calls failed, got ", bcy.Breaker.State())
+	}
+	_, err := bcy.GetChain(c)
+	var brErr *gobcy.BreakerError
+	if !errors.Is(err, gobcy.ErrCircuitOpen) || !errors.As(err, &brErr) || brErr.Wait <= 0 {
+		t.Fatal("Expected an open breaker error, got ", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err = bcy.GetChain(c); err != nil {
+		t.Fatal("GetChain probe error encountered: ", err)
+	}
+	want := "closed>open,open>half-open,half-open>closed"
+	if got := strings.Join(changes, ","); got != want {
+		t.Errorf("Expected state changes %v, got %v", want, got)
+	}
+}
+
+func TestBreakerDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"name": "BCY.test"}`))
+	}))
+	defer srv.Close()
+	bcy := gobcy.NewAPI("test-token", "bcy", "test", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	bcy.Breaker = &gobcy.CircuitBreaker{ConsecutiveTimeouts: 1}
+	//the caller's own deadline says nothing about the server
+	for i := 0; i < 3; i++ {
+		c, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		_, err := bcy.GetChain(c)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatal("Expected the caller's deadline to pass, got ", err)
+		}
+	}
+	if state := bcy.Breaker.State(); state != gobcy.BreakerClosed {
+		t.Error("Expected caller deadlines to leave the breaker closed, got ", state)
+	}
+}
+
+func TestBreakerSlowBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//the status comes right away, the body too late
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name": `))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	client := srv.Client()
+	client.Timeout = 50 * time.Millisecond
+	bcy := gobcy.NewAPI("test-token", "bcy", "test", client)
+	bcy.BaseURL = srv.URL + "/v1/"
+	bcy.Breaker = &gobcy.CircuitBreaker{ConsecutiveTimeouts: 2}
+	c := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := bcy.GetChain(c); err == nil {
+			t.Fatal("Expected the body read to time out")
+		}
+	}
+	if state := bcy.Breaker.State(); state != gobcy.BreakerOpen {
+		t.Error("Expected body timeouts to open the breaker, got ", state)
+	}
+}
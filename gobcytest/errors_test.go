@@ -0,0 +1,92 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestAPIError(t *testing.T) {
+	c := context.Background()
+	var status int
+	var header, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header != "" {
+			w.Header().Set("Retry-After", header)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	bcy := gobcy.NewAPI("test-token", "btc", "main", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	getChain := func() error {
+		_, err := bcy.GetChain(c)
+		return err
+	}
+	newTX := func() error {
+		_, err := bcy.NewTX(c, gobcy.TempNewTX("from", "to", *big.NewInt(1)), false)
+		return err
+	}
+	tests := []struct {
+		status       int
+		header, body string
+		call         func() error
+		sentinel     error
+		method, path string
+		message      string
+		messages     []string
+		retryAfter   time.Duration
+	}{
+		{404, "", `{"error": "Transaction not found."}`, getChain, gobcy.ErrNotFound,
+			"GET", "/v1/btc/main", "Transaction not found.", nil, 0},
+		{429, "7", `{"error": "Limits reached."}`, getChain, gobcy.ErrRateLimited,
+			"GET", "/v1/btc/main", "Limits reached.", nil, 7 * time.Second},
+		{429, "", `{"error": "Limits reached.", "retry_after": 3}`, getChain, gobcy.ErrRateLimited,
+			"GET", "/v1/btc/main", "Limits reached.", nil, 3 * time.Second},
+		{401, "", `{"error": "Unauthorized"}`, getChain, gobcy.ErrUnauthorized,
+			"GET", "/v1/btc/main", "Unauthorized", nil, 0},
+		{403, "", `not json`, getChain, gobcy.ErrUnauthorized,
+			"GET", "/v1/btc/main", "", nil, 0},
+		{400, "", `{"errors": [{"error": "Not enough funds in 1 inputs to pay for 1 outputs, missing -1."}, {"error": "Error validating generated transaction."}]}`,
+			newTX, gobcy.ErrInsufficientFunds, "POST", "/v1/btc/main/txs/new", "",
+			[]string{"Not enough funds in 1 inputs to pay for 1 outputs, missing -1.", "Error validating generated transaction."}, 0},
+	}
+	sentinels := []error{gobcy.ErrNotFound, gobcy.ErrRateLimited, gobcy.ErrUnauthorized, gobcy.ErrInsufficientFunds}
+	for _, v := range tests {
+		status, header, body = v.status, v.header, v.body
+		err := v.call()
+		var apiErr *gobcy.APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("Expected an *APIError for HTTP %v, got %v", v.status, err)
+			continue
+		}
+		if apiErr.StatusCode != v.status || apiErr.Method != v.method || apiErr.Endpoint != v.path {
+			t.Errorf("Unexpected status or request in %+v", apiErr)
+		}
+		if apiErr.Message != v.message || strings.Join(apiErr.Messages, "|") != strings.Join(v.messages, "|") {
+			t.Errorf("Unexpected messages in %+v", apiErr)
+		}
+		if apiErr.RetryAfter != v.retryAfter {
+			t.Errorf("Expected RetryAfter %v for HTTP %v, got %v", v.retryAfter, v.status, apiErr.RetryAfter)
+		}
+		for _, s := range sentinels {
+			if errors.Is(err, s) != (s == v.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = %v", err, s, !(s == v.sentinel))
+			}
+		}
+	}
+	//Retry-After may also be an HTTP date
+	status, header, body = 429, time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), ""
+	var apiErr *gobcy.APIError
+	if err := getChain(); !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 || apiErr.RetryAfter > time.Minute {
+		t.Errorf("Expected a RetryAfter of up to a minute, got %v", err)
+	}
+}
@@ -0,0 +1,862 @@
+//Tests for the fake BlockCypher server, driven through
+//the gobcy client like the live tests in gobcy_test.go.
+package gobcytest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+	"github.com/ThePiachu/gobcy/v2/gobcytest"
+)
+
+//setup starts a Server with one funded, confirmed address.
+func setup(t *testing.T) (*gobcytest.Server, gobcy.API, gobcy.AddrKeychain) {
+	srv := gobcytest.NewServer("bcy", "test")
+	t.Cleanup(srv.Close)
+	bcy := srv.API("test-token")
+	keys, err := bcy.GenAddrKeychain(context.Background())
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	if _, err = bcy.Faucet(context.Background(), keys, 1e6); err != nil {
+		t.Fatal("Faucet error encountered: ", err)
+	}
+	srv.Mine(1)
+	return srv, bcy, keys
+}
+
+func TestBlockchain(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	srv.Mine(2)
+	ch, err := bcy.GetChain(c)
+	if err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	if ch.Height != 3 || ch.Name != "BCY.test" {
+		t.Errorf("GetChain returned %+v, expected BCY.test at height 3", ch)
+	}
+	bl, err := bcy.GetBlock(c, 1, "", nil)
+	if err != nil {
+		t.Fatal("GetBlock error encountered: ", err)
+	}
+	if bl.NumTX != 1 || bl.Depth != 2 {
+		t.Errorf("GetBlock returned %+v, expected 1 tx at depth 2", bl)
+	}
+	if _, err = bcy.GetBlock(c, 0, "nope", nil); !errors.Is(err, gobcy.ErrNotFound) {
+		t.Error("Expected ErrNotFound for unknown block, got: ", err)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	srv.Fund(keys.Address, 5000)
+	addr, err := bcy.GetAddrBal(c, keys.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if addr.Balance.Int64() != 1e6 || addr.UnconfirmedBalance.Int64() != 5000 || addr.FinalNumTX != 2 {
+		t.Errorf("GetAddrBal returned %+v", addr)
+	}
+	addr, err = bcy.GetAddr(c, keys.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddr error encountered: ", err)
+	}
+	if len(addr.TXRefs) != 1 || len(addr.UnconfirmedTXRefs) != 1 {
+		t.Errorf("GetAddr returned %d confirmed and %d unconfirmed refs, expected 1 and 1", len(addr.TXRefs), len(addr.UnconfirmedTXRefs))
+	}
+	addr, err = bcy.GetAddrFull(c, keys.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrFull error encountered: ", err)
+	}
+	if len(addr.TXs) != 2 {
+		t.Errorf("GetAddrFull returned %d txs, expected 2", len(addr.TXs))
+	}
+	if _, err = bcy.GetAddr(c, "not-an-address", nil); err == nil {
+		t.Error("Expected error for invalid address, did not receive one")
+	}
+}
+
+func TestTX(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	dest, err := bcy.GenAddrKeychain(c)
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	skel, err := bcy.NewTX(c, gobcy.TempNewTX(keys.Address, dest.Address, *big.NewInt(45000)), true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Sign([]string{keys.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	skel, err = bcy.SendTX(c, skel)
+	if err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	conf, err := bcy.GetTXConf(c, skel.Trans.Hash)
+	if err != nil || conf.Confidence == 1 {
+		t.Errorf("GetTXConf returned %+v, %v; expected unconfirmed", conf, err)
+	}
+	srv.Mine(1)
+	tx, err := bcy.GetTX(c, skel.Trans.Hash, nil)
+	if err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	if tx.Confirmations != 1 || tx.Fees.Int64() != srv.Fee {
+		t.Errorf("GetTX returned %+v, expected 1 confirmation and default fee", tx)
+	}
+	addr, err := bcy.GetAddrBal(c, dest.Address, nil)
+	if err != nil || addr.Balance.Int64() != 45000 {
+		t.Errorf("GetAddrBal returned %+v, %v; expected balance 45000", addr, err)
+	}
+	_, err = bcy.NewTX(c, gobcy.TempNewTX(dest.Address, keys.Address, *big.NewInt(1e7)), false)
+	if !errors.Is(err, gobcy.ErrInsufficientFunds) {
+		t.Error("Expected ErrInsufficientFunds, got: ", err)
+	}
+}
+
+func TestWallet(t *testing.T) {
+	c := context.Background()
+	_, bcy, keys := setup(t)
+	if _, err := bcy.CreateWallet(c, gobcy.Wallet{Name: "testwallet", Addresses: []string{keys.Address}}); err != nil {
+		t.Fatal("CreateWallet error encountered: ", err)
+	}
+	wal, _, err := bcy.GenAddrWallet(c, "testwallet")
+	if err != nil {
+		t.Fatal("GenAddrWallet error encountered: ", err)
+	}
+	if len(wal.Addresses) != 2 {
+		t.Errorf("GenAddrWallet returned %+v, expected 2 addresses", wal)
+	}
+	addr, err := bcy.GetAddrBal(c, "testwallet", nil)
+	if err != nil || addr.Balance.Int64() != 1e6 {
+		t.Errorf("GetAddrBal on wallet returned %+v, %v; expected balance 1000000", addr, err)
+	}
+	if err = bcy.DeleteWallet(c, "testwallet"); err != nil {
+		t.Error("DeleteWallet error encountered: ", err)
+	}
+	if _, err = bcy.GetWallet(c, "testwallet"); !errors.Is(err, gobcy.ErrNotFound) {
+		t.Error("Expected ErrNotFound for deleted wallet, got: ", err)
+	}
+}
+
+func TestHDWallet(t *testing.T) {
+	c := context.Background()
+	_, bcy, _ := setup(t)
+	_, err := bcy.CreateHDWallet(c, gobcy.HDWallet{Name: "testhdwallet", ExtPubKey: "xpub-test"})
+	if err != nil {
+		t.Fatal("CreateHDWallet error encountered: ", err)
+	}
+	newhd, err := bcy.DeriveAddrHDWallet(c, "testhdwallet", nil)
+	if err != nil || len(newhd.Chains) != 1 || len(newhd.Chains[0].ChainAddr) != 1 {
+		t.Errorf("DeriveAddrHDWallet returned %+v, %v; expected one new address", newhd, err)
+	}
+	addrs, err := bcy.GetAddrHDWallet(c, "testhdwallet", nil)
+	if err != nil || len(addrs.Chains[0].ChainAddr) != 2 {
+		t.Errorf("GetAddrHDWallet returned %+v, %v; expected two addresses", addrs, err)
+	}
+	if err = bcy.DeleteHDWallet(c, "testhdwallet"); err != nil {
+		t.Error("DeleteHDWallet error encountered: ", err)
+	}
+}
+
+func TestHook(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	hook, err := bcy.CreateHook(c, gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callbacks"})
+	if err != nil {
+		t.Fatal("CreateHook error encountered: ", err)
+	}
+	other := srv.API("other-token")
+	if _, err = other.GetHook(c, hook.ID); !errors.Is(err, gobcy.ErrNotFound) {
+		t.Error("Expected hooks to be scoped to their token, got: ", err)
+	}
+	if err = bcy.DeleteHook(c, hook.ID); err != nil {
+		t.Error("DeleteHook error encountered: ", err)
+	}
+	hooks, err := bcy.ListHooks(c)
+	if err != nil || len(hooks) != 0 {
+		t.Errorf("ListHooks returned %+v, %v; expected none", hooks, err)
+	}
+}
+
+func TestPayFwd(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	pay, err := bcy.CreatePayFwd(c, gobcy.PayFwd{Destination: keys.Address})
+	if err != nil {
+		t.Fatal("CreatePayFwd error encountered: ", err)
+	}
+	srv.Fund(pay.InputAddr, 50000)
+	srv.Mine(1)
+	pay, err = bcy.GetPayFwd(c, pay.ID)
+	if err != nil || len(pay.TXHistory) != 1 {
+		t.Fatalf("GetPayFwd returned %+v, %v; expected one forward", pay, err)
+	}
+	addr, err := bcy.GetAddrBal(c, keys.Address, nil)
+	if err != nil || addr.Balance.Int64() != 1e6+50000-srv.Fee {
+		t.Errorf("GetAddrBal returned %+v, %v; expected forwarded funds", addr, err)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	c := context.Background()
+	_, bcy, keys := setup(t)
+	if err := bcy.PutMeta(c, keys.Address, "addr", true, map[string]string{"key": "value"}); err != nil {
+		t.Fatal("PutMeta error encountered: ", err)
+	}
+	data, err := bcy.GetMeta(c, keys.Address, "addr", true)
+	if err != nil || data["key"] != "value" {
+		t.Errorf("GetMeta returned %v, %v; expected key=value", data, err)
+	}
+	if err = bcy.DeleteMeta(c, keys.Address, "addr"); err != nil {
+		t.Error("DeleteMeta error encountered: ", err)
+	}
+}
+
+func TestAsset(t *testing.T) {
+	c := context.Background()
+	srv, bcy, funder := setup(t)
+	oap1, err := bcy.GenAssetKeychain(c)
+	if err != nil {
+		t.Fatal("GenAssetKeychain error encountered: ", err)
+	}
+	oap2, err := bcy.GenAssetKeychain(c)
+	if err != nil {
+		t.Fatal("GenAssetKeychain error encountered: ", err)
+	}
+	if _, err = bcy.Faucet(c, oap1, 1e5); err != nil {
+		t.Fatal("Faucet error encountered: ", err)
+	}
+	tx1, err := bcy.IssueAsset(c, gobcy.OAPIssue{Priv: funder.Private, ToAddr: oap1.OAPAddress, Amount: *big.NewInt(9000)})
+	if err != nil {
+		t.Fatal("IssueAsset error encountered: ", err)
+	}
+	srv.Mine(1)
+	if _, err = bcy.TransferAsset(c, gobcy.OAPIssue{Priv: oap1.Private, ToAddr: oap2.OAPAddress, Amount: *big.NewInt(8999)}, tx1.AssetID); err != nil {
+		t.Fatal("TransferAsset error encountered: ", err)
+	}
+	txs, err := bcy.ListAssetTXs(c, tx1.AssetID)
+	if err != nil || len(txs) != 2 {
+		t.Errorf("ListAssetTXs returned %v, %v; expected 2 txs", txs, err)
+	}
+	addr, err := bcy.GetAssetAddr(c, tx1.AssetID, oap2.OAPAddress)
+	if err != nil || addr.Balance.Int64() != 8999 {
+		t.Errorf("GetAssetAddr returned %+v, %v; expected 8999 units", addr, err)
+	}
+}
+
+func TestCassette(t *testing.T) {
+	c := context.Background()
+	srv, _, keys := setup(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := gobcytest.NewRecorder(path, gobcytest.ModeAuto, srv.Client().Transport)
+	if err != nil || !rec.Recording() {
+		t.Fatal("NewRecorder should record when the cassette is missing: ", err)
+	}
+	bcy := gobcy.NewAPIWithTransport("secret-token", "bcy", "test", rec)
+	bcy.BaseURL = srv.URL + "/v1/"
+	want, err := bcy.GetAddr(c, keys.Address, map[string]string{"limit": "5", "unspentOnly": "true"})
+	if err != nil {
+		t.Fatal("GetAddr error encountered: ", err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal("Save error encountered: ", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("Cassette contains the token")
+	}
+	srv.Close()
+
+	rec, err = gobcytest.NewRecorder(path, gobcytest.ModeAuto, nil)
+	if err != nil || rec.Recording() {
+		t.Fatal("NewRecorder should replay an existing cassette: ", err)
+	}
+	bcy = gobcy.NewAPIWithTransport("another-token", "bcy", "test", rec)
+	bcy.BaseURL = srv.URL + "/v1/"
+	got, err := bcy.GetAddr(c, keys.Address, map[string]string{"unspentOnly": "true", "limit": "5"})
+	if err != nil || got.Balance.Cmp(&want.Balance) != 0 {
+		t.Errorf("Replayed GetAddr returned %+v, %v; expected %+v", got, err, want)
+	}
+	if _, err = bcy.GetChain(c); err == nil || len(rec.Unmatched()) != 1 {
+		t.Error("Expected unrecorded request to fail, got: ", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	bcy.Cache = gobcy.NewCache(100)
+	hash := srv.Fund(keys.Address, 1000)
+	if _, err := bcy.GetTX(c, hash, nil); err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	srv.FailNext(500, 1)
+	if _, err := bcy.GetTX(c, hash, nil); err == nil {
+		t.Error("Unconfirmed TX should not be served from the cache")
+	}
+	srv.Mine(6)
+	if _, err := bcy.GetTX(c, hash, nil); err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	srv.FailNext(500, 1)
+	tx, err := bcy.GetTX(c, hash, nil)
+	if err != nil || tx.Confirmations != 6 {
+		t.Errorf("Expected TX with 6 confirmations from the cache, got %+v, %v", tx, err)
+	}
+	if _, err = bcy.GetChain(c); err == nil {
+		t.Error("GetChain should not be cached without a MutableTTL")
+	}
+	//wallet names are per token, so their balances must not be shared
+	bcy.Cache.MutableTTL = time.Minute
+	other := srv.API("other-token")
+	other.Cache = bcy.Cache
+	otherKeys, err := other.GenAddrKeychain(c)
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	srv.Fund(otherKeys.Address, 500)
+	srv.Mine(1)
+	for _, v := range []struct {
+		api  gobcy.API
+		addr string
+	}{{bcy, keys.Address}, {other, otherKeys.Address}} {
+		if _, err = v.api.CreateWallet(c, gobcy.Wallet{Name: "shared", Addresses: []string{v.addr}}); err != nil {
+			t.Fatal("CreateWallet error encountered: ", err)
+		}
+	}
+	mine, err := bcy.GetAddrBal(c, "shared", nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	theirs, err := other.GetAddrBal(c, "shared", nil)
+	if err != nil || theirs.Balance.Int64() != 500 || mine.Balance.Int64() == 500 {
+		t.Errorf("Wallet balances leaked between tokens through the cache: %v and %v, %v", mine.Balance.String(), theirs.Balance.String(), err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	c := context.Background()
+	srv, _, _ := setup(t)
+	var agents []string
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		handler.ServeHTTP(w, r)
+	})
+	if _, err := gobcy.NewClient("test-token", "btc", "mian", nil); err == nil {
+		t.Error("Expected error from NewClient with an unknown chain, did not receive one")
+	}
+	cl, err := gobcy.NewClient("test-token", "bcy", "test", &gobcy.ClientOptions{UserAgent: "gobcytest"})
+	if err != nil {
+		t.Fatal("NewClient error encountered: ", err)
+	}
+	defer cl.Close()
+	cl.BaseURL = srv.URL + "/v1/"
+	for i := 0; i < 2; i++ {
+		if _, err = cl.GetChain(c); err != nil {
+			t.Fatal("GetChain error encountered: ", err)
+		}
+	}
+	if len(agents) != 2 || agents[0] != "gobcytest" {
+		t.Errorf("Unexpected User-Agents: %v", agents)
+	}
+}
+
+func TestTokenPool(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	srv.Tokens = []string{"test-token", "token-a", "token-b"}
+	bcy.Token = ""
+	bcy.Tokens = gobcy.NewTokenPool("revoked", "token-a", "token-b")
+	if err := bcy.Tokens.Refresh(c, &bcy); !errors.Is(err, gobcy.ErrUnauthorized) {
+		t.Error("Expected Refresh to report the revoked token, got: ", err)
+	}
+	var hooks []gobcy.Hook
+	for i := 0; i < 4; i++ {
+		hook, err := bcy.CreateHook(c, gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callbacks"})
+		if err != nil {
+			t.Fatal("CreateHook error encountered: ", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	srv.FailNext(429, 1)
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Fatal("GetChain did not fail over after a 429: ", err)
+	}
+	list, err := bcy.ListHooks(c)
+	if err != nil {
+		t.Fatal("ListHooks error encountered: ", err)
+	}
+	if len(list) != len(hooks) {
+		t.Errorf("ListHooks merged %v hooks, expected %v", len(list), len(hooks))
+	}
+	for _, v := range hooks {
+		if _, err = bcy.GetHook(c, v.ID); err != nil {
+			t.Error("GetHook error encountered: ", err)
+		}
+	}
+	//a fresh pool finds the owners by asking every token
+	other := bcy
+	other.Tokens = gobcy.NewTokenPool("token-a", "token-b")
+	for _, v := range hooks {
+		if err = other.DeleteHook(c, v.ID); err != nil {
+			t.Error("DeleteHook error encountered: ", err)
+		}
+	}
+	if list, _ = bcy.ListHooks(c); len(list) != 0 {
+		t.Errorf("ListHooks returned %v hooks after deleting them all", len(list))
+	}
+	//wallets stay with their token too, also as Address API names
+	bcy.Tokens = gobcy.NewTokenPool("token-a", "token-b")
+	keys, err := bcy.GenerateKeychain(gobcy.P2PKH)
+	if err != nil {
+		t.Fatal("GenerateKeychain error encountered: ", err)
+	}
+	srv.Fund(keys.Address, 2000)
+	srv.Mine(1)
+	if _, err = bcy.CreateWallet(c, gobcy.Wallet{Name: "pooled", Addresses: []string{keys.Address}}); err != nil {
+		t.Fatal("CreateWallet error encountered: ", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err = bcy.GetWallet(c, "pooled"); err != nil {
+			t.Error("GetWallet error encountered: ", err)
+		}
+		if bal, err := bcy.GetAddrBal(c, "pooled", nil); err != nil || bal.Balance.Int64() != 2000 {
+			t.Errorf("GetAddrBal of a pooled wallet returned %v, %v", bal.Balance.String(), err)
+		}
+	}
+	if set, err := bcy.ListUnspentWallet(c, "pooled", 1); err != nil || set.Total.Int64() != 2000 {
+		t.Errorf("ListUnspentWallet of a pooled wallet returned %+v, %v", set, err)
+	}
+	if names, err := other.ListWallets(c); err != nil || len(names) != 1 {
+		t.Errorf("ListWallets returned %v, %v", names, err)
+	}
+	more, err := bcy.GenerateKeychain(gobcy.P2PKH)
+	if err != nil {
+		t.Fatal("GenerateKeychain error encountered: ", err)
+	}
+	if _, err = other.AddAddrWallet(c, "pooled", []string{more.Address}, true); err != nil {
+		t.Error("AddAddrWallet error encountered: ", err)
+	}
+	if err = other.DeleteWallet(c, "pooled"); err != nil {
+		t.Error("DeleteWallet error encountered: ", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	srv.Fund(keys.Address, 5000)
+	srv.Mine(1)
+	srv.Fund(keys.Address, 7000)
+	addr, err := bcy.GetAddrOpts(c, keys.Address, &gobcy.AddrOptions{UnspentOnly: true, ConfirmationsMin: 1})
+	if err != nil {
+		t.Fatal("GetAddrOpts error encountered: ", err)
+	}
+	if len(addr.TXRefs) != 2 || len(addr.UnconfirmedTXRefs) != 0 {
+		t.Errorf("Expected 2 confirmed TXRefs, got %+v", addr)
+	}
+	if addr, err = bcy.GetAddrOpts(c, keys.Address, &gobcy.AddrOptions{ConfirmationsMax: 1}); err != nil || len(addr.TXRefs) != 1 {
+		t.Errorf("Expected 1 TXRef with at most 1 confirmation, got %+v, %v", addr.TXRefs, err)
+	}
+	if _, err = bcy.GetAddrFullOpts(c, keys.Address, &gobcy.AddrOptions{Limit: 100}); err == nil || !strings.Contains(err.Error(), "0 (default) or 1..50") {
+		t.Error("Expected error from an out of range Limit, got: ", err)
+	}
+	if _, err = bcy.GetAddrOpts(c, keys.Address, &gobcy.AddrOptions{Before: 3, After: 5}); err == nil {
+		t.Error("Expected error from After above Before, did not receive one")
+	}
+	block, err := bcy.GetBlockOpts(c, 1, "", &gobcy.BlockOptions{Limit: 1})
+	if err != nil || len(block.TXids) != 1 {
+		t.Errorf("Expected 1 TXid from GetBlockOpts, got %+v, %v", block.TXids, err)
+	}
+	tx, err := bcy.GetTXOpts(c, addr.TXRefs[0].TXHash, &gobcy.TXOptions{OutStart: 1})
+	if err != nil || len(tx.Outputs) != 0 {
+		t.Errorf("Expected no outputs past OutStart, got %+v, %v", tx.Outputs, err)
+	}
+	if _, err = bcy.GetTXOpts(c, tx.Hash, &gobcy.TXOptions{Limit: 200}); err == nil {
+		t.Error("Expected error from an out of range Limit, did not receive one")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	bcy.Batch = &gobcy.BatchPolicy{Size: 2, Concurrency: 2}
+	var hashes, addrs []string
+	for i := 0; i < 4; i++ {
+		dest, err := bcy.GenAddrKeychain(c)
+		if err != nil {
+			t.Fatal("GenAddrKeychain error encountered: ", err)
+		}
+		hashes = append(hashes, srv.Fund(dest.Address, int64(1000*(i+1))))
+		addrs = append(addrs, dest.Address)
+	}
+	srv.Mine(1)
+	missing := strings.Repeat("ab", 32)
+	ids := append([]string{missing}, hashes...)
+	ids = append(ids, hashes[0])
+	txs, err := bcy.GetTXs(c, ids, nil)
+	if err != nil {
+		t.Fatal("GetTXs error encountered: ", err)
+	}
+	if len(txs) != len(ids) || !errors.Is(txs[0].Err, gobcy.ErrNotFound) {
+		t.Fatalf("Expected a missing first TX, got %+v", txs)
+	}
+	for i, v := range txs[1:] {
+		if v.Err != nil || v.Value.Hash != ids[i+1] {
+			t.Errorf("Expected TX %v at %v, got %+v", ids[i+1], i+1, v)
+		}
+	}
+	full, err := bcy.GetAddrsFull(c, append(addrs, keys.Address), &gobcy.AddrOptions{Limit: 5})
+	if err != nil {
+		t.Fatal("GetAddrsFull error encountered: ", err)
+	}
+	for i, v := range full[:len(addrs)] {
+		if v.Err != nil || v.Value.Address != addrs[i] || v.Value.Balance.Int64() != int64(1000*(i+1)) {
+			t.Errorf("Unexpected address at %v: %+v", i, v)
+		}
+	}
+	blocks, err := bcy.GetBlocks(c, []string{"2", "1"}, nil)
+	if err != nil || blocks[0].Value.Height != 2 || blocks[1].Value.Height != 1 {
+		t.Errorf("Unexpected GetBlocks results %+v, %v", blocks, err)
+	}
+	bals, err := bcy.GetMultiAddrBal(c, addrs)
+	if err != nil || len(bals) != len(addrs) || bals[3].Address != addrs[3] {
+		t.Errorf("Unexpected GetMultiAddrBal results %+v, %v", bals, err)
+	}
+	//every id of a batch counts against the limiter
+	bcy.Batch = nil
+	bcy.Limiter = gobcy.NewRateLimiter(gobcy.Usage{PerHour: 3})
+	bcy.Limiter.FailFast = true
+	if _, err = bcy.GetTXs(c, hashes, nil); err != nil {
+		t.Fatal("GetTXs error encountered: ", err)
+	}
+	if _, err = bcy.GetChain(c); !errors.Is(err, gobcy.ErrRateLimited) {
+		t.Errorf("Expected a batch of %v to exhaust a budget of 3 calls, got %v", len(hashes), err)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.RawQuery]++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		handler.ServeHTTP(w, r)
+	})
+	bcy.Coalesce = new(gobcy.Coalescer)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		params := map[string]string{"includeScript": strconv.FormatBool(i%2 == 0)}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addr, err := bcy.GetAddr(c, keys.Address, params)
+			if err != nil || addr.Address != keys.Address {
+				t.Errorf("Unexpected GetAddr result %+v, %v", addr, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(hits) != 2 {
+		t.Errorf("Expected the two parameter sets to stay apart, got %v", hits)
+	}
+	for k, v := range hits {
+		if v != 1 {
+			t.Errorf("Expected 1 request for %v, got %v", k, v)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	bcy.Strict = true
+	if _, err := bcy.GetAddrFull(c, keys.Address, nil); err != nil {
+		t.Fatal("GetAddrFull error encountered in Strict mode: ", err)
+	}
+	if _, err := bcy.GetBlock(c, 1, "", nil); err != nil {
+		t.Fatal("GetBlock error encountered in Strict mode: ", err)
+	}
+	//GenAddrWallet decodes into embedded *Wallet and *AddrKeychain
+	if _, err := bcy.CreateWallet(c, gobcy.Wallet{Name: "strict", Addresses: []string{keys.Address}}); err != nil {
+		t.Fatal("CreateWallet error encountered in Strict mode: ", err)
+	}
+	wal, addr, err := bcy.GenAddrWallet(c, "strict")
+	if err != nil || len(wal.Addresses) != 2 || addr.Address == "" || addr.Private == "" {
+		t.Fatalf("Expected a new wallet address in Strict mode, got %+v, %+v, %v", wal, addr, err)
+	}
+	//simulate a server changing the chain endpoint
+	body := `{"name": "BCY.test", "height": 12, "relay_stats": {"in": 3}}`
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/bcy/test" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	})
+	bcy.Strict = false
+	chain, err := bcy.GetChain(c)
+	if err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	if string(chain.Extra["relay_stats"]) != `{"in": 3}` {
+		t.Errorf("Expected relay_stats in Extra, got %v", chain.Extra)
+	}
+	data, err := json.Marshal(chain)
+	if err != nil || !strings.Contains(string(data), `"relay_stats":{"in":3}`) {
+		t.Errorf("Expected Extra to be marshalled back, got %s, %v", data, err)
+	}
+	bcy.Strict = true
+	_, err = bcy.GetChain(c)
+	var schemaErr *gobcy.SchemaError
+	if !errors.As(err, &schemaErr) || len(schemaErr.Issues) != 1 || schemaErr.Issues[0].Path != "relay_stats" {
+		t.Errorf("Expected a SchemaError for relay_stats, got %v", err)
+	}
+	body = `{"name": "BCY.test", "height": "12", "time": 1}`
+	_, err = bcy.GetChain(c)
+	if !errors.As(err, &schemaErr) || len(schemaErr.Issues) != 2 || schemaErr.Err == nil {
+		t.Errorf("Expected a SchemaError for height and time, got %v", err)
+	}
+}
+
+func TestAddrHistory(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	for _, n := range []int{2, 3, 1} {
+		for i := 0; i < n; i++ {
+			srv.Fund(keys.Address, 1000)
+		}
+		srv.Mine(1)
+	}
+	synced := srv.Height()
+	srv.Fund(keys.Address, 1000)
+	srv.Mine(1)
+	srv.Fund(keys.Address, 1000)
+	all, err := bcy.GetAddr(c, keys.Address, map[string]string{"limit": "2000"})
+	if err != nil {
+		t.Fatal("GetAddr error encountered: ", err)
+	}
+	seen := map[string]int{}
+	for ref, err := range bcy.AddrHistory(c, keys.Address, &gobcy.AddrOptions{Limit: 3}) {
+		if err != nil {
+			t.Fatal("AddrHistory error encountered: ", err)
+		}
+		seen[ref.TXHash+":"+strconv.Itoa(ref.TXOutputN)]++
+	}
+	if len(seen) != len(all.TXRefs)+len(all.UnconfirmedTXRefs) {
+		t.Errorf("Expected %v TXRefs, got %v", len(all.TXRefs)+len(all.UnconfirmedTXRefs), len(seen))
+	}
+	for k, v := range seen {
+		if v != 1 {
+			t.Errorf("TXRef %v came up %v times", k, v)
+		}
+	}
+	cur := bcy.NewAddrFullCursor(c, keys.Address, &gobcy.AddrOptions{After: synced, Limit: 1})
+	var heights []int
+	for cur.Next() {
+		heights = append(heights, cur.Value().BlockHeight)
+	}
+	if cur.Err() != nil || len(heights) != 2 || heights[0] != -1 || heights[1] != synced+1 {
+		t.Errorf("Expected the unconfirmed TX and one after height %v, got %v, %v", synced, heights, cur.Err())
+	}
+	for _, err := range bcy.AddrHistory(c, keys.Address, &gobcy.AddrOptions{Limit: 5000}) {
+		if err == nil {
+			t.Error("Expected error from an out of range Limit, did not receive one")
+		}
+	}
+	//a block larger than a page
+	srv.Mine(1)
+	for i := 0; i < 5; i++ {
+		srv.Fund(keys.Address, 1000)
+	}
+	srv.Mine(1)
+	if all, err = bcy.GetAddr(c, keys.Address, map[string]string{"limit": "2000"}); err != nil {
+		t.Fatal("GetAddr error encountered: ", err)
+	}
+	n := 0
+	for _, err := range bcy.AddrHistory(c, keys.Address, &gobcy.AddrOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatal("AddrHistory error encountered: ", err)
+		}
+		n++
+	}
+	if n != len(all.TXRefs) {
+		t.Errorf("Expected %v TXRefs with a block larger than the page, got %v", len(all.TXRefs), n)
+	}
+	//and one larger than the largest page, which can't be walked
+	for i := 0; i < 51; i++ {
+		srv.Fund(keys.Address, 1000)
+	}
+	srv.Mine(1)
+	n = 0
+	err = bcy.StreamAddrFull(c, keys.Address, &gobcy.AddrOptions{Limit: 10}, func(gobcy.TX) error {
+		n++
+		return nil
+	})
+	if err == nil {
+		t.Errorf("Expected error from a block of 51 TXs, did not receive one after %v TXs", n)
+	}
+}
+
+func TestListUnspent(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	bcy.Batch = &gobcy.BatchPolicy{Size: 2}
+	dest, err := bcy.GenAddrKeychain(c)
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	//spend the faucet output, leaving change
+	skel, err := bcy.NewTX(c, gobcy.TempNewTX(keys.Address, dest.Address, *big.NewInt(45000)), false)
+	if err == nil {
+		err = skel.Sign([]string{keys.Private})
+	}
+	if err == nil {
+		_, err = bcy.SendTX(c, skel)
+	}
+	if err != nil {
+		t.Fatal("Spending error encountered: ", err)
+	}
+	srv.Mine(1)
+	other, err := bcy.GenAddrKeychain(c)
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	srv.Fund(other.Address, 3000)
+	addrs := []string{keys.Address, dest.Address, other.Address}
+	set, err := bcy.ListUnspent(c, addrs, 0)
+	if err != nil {
+		t.Fatal("ListUnspent error encountered: ", err)
+	}
+	change := int64(1e6 - 45000 - srv.Fee)
+	if len(set.UTXOs) != 3 || set.Total.Int64() != change+45000+3000 || set.Totals[keys.Address].Int64() != change {
+		t.Errorf("Unexpected UTXOs %+v, total %v", set.UTXOs, set.Total.String())
+	}
+	for _, u := range set.UTXOs {
+		if u.TXID == "" || u.Script == "" || u.Address == "" {
+			t.Errorf("Incomplete UTXO %+v", u)
+		}
+	}
+	if set, err = bcy.ListUnspent(c, addrs, 1); err != nil || len(set.UTXOs) != 2 {
+		t.Errorf("Expected 2 confirmed UTXOs, got %+v, %v", set.UTXOs, err)
+	}
+	if _, err = bcy.CreateWallet(c, gobcy.Wallet{Name: "hot", Addresses: addrs}); err != nil {
+		t.Fatal("CreateWallet error encountered: ", err)
+	}
+	if set, err = bcy.ListUnspentWallet(c, "hot", 0); err != nil || len(set.UTXOs) != 3 || set.Totals[other.Address].Int64() != 3000 {
+		t.Errorf("Unexpected wallet UTXOs %+v, %v", set, err)
+	}
+}
+
+func TestBalanceAt(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	funded := srv.Height()
+	dest, err := bcy.GenAddrKeychain(c)
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	skel, err := bcy.NewTX(c, gobcy.TempNewTX(keys.Address, dest.Address, *big.NewInt(45000)), false)
+	if err == nil {
+		err = skel.Sign([]string{keys.Private})
+	}
+	if err == nil {
+		_, err = bcy.SendTX(c, skel)
+	}
+	if err != nil {
+		t.Fatal("Spending error encountered: ", err)
+	}
+	spent := srv.Mine(1)[0]
+	srv.Fund(keys.Address, 2000)
+	srv.Mine(2)
+	srv.Fund(keys.Address, 500)
+	change := int64(1e6 - 45000 - srv.Fee)
+	for height, want := range map[int]int64{funded - 1: 0, funded: 1e6, spent.Height: change, srv.Height(): change + 2000} {
+		if bal, err := bcy.BalanceAt(c, keys.Address, height); err != nil || bal.Int64() != want {
+			t.Errorf("BalanceAt %v returned %v, %v, expected %v", height, bal.String(), err, want)
+		}
+	}
+	points, err := bcy.BalanceSeries(c, keys.Address, 0, srv.Height(), 2)
+	if err != nil {
+		t.Fatal("BalanceSeries error encountered: ", err)
+	}
+	last := points[len(points)-1]
+	if last.Height != srv.Height() || last.Balance.Int64() != change+2000 || len(points) != srv.Height()/2+1+srv.Height()%2 {
+		t.Errorf("Unexpected BalanceSeries %+v", points)
+	}
+	times := []time.Time{spent.Time, spent.Time.Add(-time.Second)}
+	if points, err = bcy.BalanceSeriesTime(c, keys.Address, times); err != nil || len(points) != 2 ||
+		points[0].Balance.Int64() != 1e6 || points[1].Balance.Int64() != change {
+		t.Errorf("Unexpected BalanceSeriesTime %+v, %v", points, err)
+	}
+	if bal, err := bcy.BalanceAtTime(c, dest.Address, spent.Time); err != nil || bal.Int64() != 45000 {
+		t.Errorf("BalanceAtTime returned %v, %v, expected 45000", bal.String(), err)
+	}
+	if _, err = bcy.BalanceSeries(c, keys.Address, 3, 1, 1); err == nil {
+		t.Error("Expected error from a backwards BalanceSeries, did not receive one")
+	}
+}
+
+func TestValidateTX(t *testing.T) {
+	c := context.Background()
+	_, bcy, keys := setup(t)
+	if _, err := bcy.ParseAddress(keys.Address); err != nil {
+		t.Error("ParseAddress error encountered on a fake address: ", err)
+	}
+	trans := gobcy.TempNewTX(keys.Address, keys.Address, *big.NewInt(1000))
+	if err := bcy.ValidateTX(trans); err != nil {
+		t.Error("ValidateTX error encountered: ", err)
+	}
+	if _, err := bcy.NewTX(c, trans, false); err != nil {
+		t.Error("NewTX error encountered after validating: ", err)
+	}
+}
+
+func TestGenerateKeychain(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	keys, err := bcy.GenerateKeychain(gobcy.P2PKH)
+	if err != nil {
+		t.Fatal("GenerateKeychain error encountered: ", err)
+	}
+	if keys.Private == "" || keys.Public == "" || keys.Wif == "" {
+		t.Errorf("Incomplete keychain %+v", keys)
+	}
+	if _, err = bcy.Faucet(c, keys, 1e5); err != nil {
+		t.Fatal("Faucet error encountered: ", err)
+	}
+	srv.Mine(1)
+	skel, err := bcy.NewTX(c, gobcy.TempNewTX(keys.Address, keys.Address, *big.NewInt(5000)), false)
+	if err == nil {
+		err = skel.Sign([]string{keys.Private})
+	}
+	if err == nil {
+		_, err = bcy.SendTX(c, skel)
+	}
+	if err != nil {
+		t.Fatal("Spending from a local keychain error encountered: ", err)
+	}
+}
@@ -0,0 +1,184 @@
+package gobcytest
+
+import (
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//hookEvents lists the events a WebHook can subscribe to.
+var hookEvents = []string{
+	"unconfirmed-tx", "new-block", "confirmed-tx",
+	"tx-confirmation", "double-spend-tx", "tx-confidence",
+}
+
+//createHook answers POST /hooks. Hooks are stored per token
+//but never called back.
+func (s *Server) createHook(c *call) (interface{}, *apiError) {
+	var hook gobcy.Hook
+	if err := c.decode(&hook); err != nil {
+		return nil, err
+	}
+	if !contains(hookEvents, hook.Event) {
+		return nil, errorf(http.StatusBadRequest, "Invalid event: "+hook.Event+".")
+	}
+	if hook.URL == "" {
+		return nil, errorf(http.StatusBadRequest, "A callback url is required.")
+	}
+	hook.ID = s.newID("hook")
+	if s.hooks[c.token] == nil {
+		s.hooks[c.token] = make(map[string]*gobcy.Hook)
+	}
+	s.hooks[c.token][hook.ID] = &hook
+	return hook, nil
+}
+
+//listHooks answers GET /hooks.
+func (s *Server) listHooks(c *call) (interface{}, *apiError) {
+	hooks := []gobcy.Hook{}
+	for _, v := range s.hooks[c.token] {
+		hooks = append(hooks, *v)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks, nil
+}
+
+//getHook answers GET /hooks/{id}.
+func (s *Server) getHook(c *call) (interface{}, *apiError) {
+	hook := s.hooks[c.token][c.parts[1]]
+	if hook == nil {
+		return nil, errorf(http.StatusNotFound, "Hook "+c.parts[1]+" not found.")
+	}
+	return *hook, nil
+}
+
+//deleteHook answers DELETE /hooks/{id}.
+func (s *Server) deleteHook(c *call) (interface{}, *apiError) {
+	if s.hooks[c.token][c.parts[1]] == nil {
+		return nil, errorf(http.StatusNotFound, "Hook "+c.parts[1]+" not found.")
+	}
+	delete(s.hooks[c.token], c.parts[1])
+	return nil, nil
+}
+
+//createPayFwd answers POST /payments, generating the input
+//address that forwards to the destination.
+func (s *Server) createPayFwd(c *call) (interface{}, *apiError) {
+	var pay gobcy.PayFwd
+	if err := c.decode(&pay); err != nil {
+		return nil, err
+	}
+	if !s.validAddr(pay.Destination) {
+		return nil, errorf(http.StatusBadRequest, "Invalid destination address: "+pay.Destination+".")
+	}
+	if pay.ProcessAddr != "" && !s.validAddr(pay.ProcessAddr) {
+		return nil, errorf(http.StatusBadRequest, "Invalid process_fees_address: "+pay.ProcessAddr+".")
+	}
+	key, _ := s.newKey()
+	pay.ID = s.newID("payfwd")
+	pay.InputAddr = key.address
+	if pay.MiningFees == 0 {
+		pay.MiningFees = int(s.Fee)
+	}
+	if s.payfwds[c.token] == nil {
+		s.payfwds[c.token] = make(map[string]*gobcy.PayFwd)
+	}
+	s.payfwds[c.token][pay.ID] = &pay
+	return pay, nil
+}
+
+//listPayFwds answers GET /payments, paged by "start".
+func (s *Server) listPayFwds(c *call) (interface{}, *apiError) {
+	pays := []gobcy.PayFwd{}
+	for _, v := range s.payfwds[c.token] {
+		pays = append(pays, *v)
+	}
+	sort.Slice(pays, func(i, j int) bool { return pays[i].ID < pays[j].ID })
+	start := c.intParam("start", 0)
+	if start > len(pays) {
+		start = len(pays)
+	}
+	pays = pays[start:]
+	if len(pays) > 200 {
+		pays = pays[:200]
+	}
+	return pays, nil
+}
+
+//getPayFwd answers GET /payments/{id}.
+func (s *Server) getPayFwd(c *call) (interface{}, *apiError) {
+	pay := s.payfwds[c.token][c.parts[1]]
+	if pay == nil {
+		return nil, errorf(http.StatusNotFound, "Payment forward "+c.parts[1]+" not found.")
+	}
+	return *pay, nil
+}
+
+//deletePayFwd answers DELETE /payments/{id}.
+func (s *Server) deletePayFwd(c *call) (interface{}, *apiError) {
+	if s.payfwds[c.token][c.parts[1]] == nil {
+		return nil, errorf(http.StatusNotFound, "Payment forward "+c.parts[1]+" not found.")
+	}
+	delete(s.payfwds[c.token], c.parts[1])
+	return nil, nil
+}
+
+//forwardPayments forwards any output of tx that pays the input
+//address of a payment forward, in a new mempool transaction.
+func (s *Server) forwardPayments(tx gobcy.TX) {
+	for _, pays := range s.payfwds {
+		for _, pay := range pays {
+			for i, out := range tx.Outputs {
+				if firstAddr(out.Addresses) != pay.InputAddr {
+					continue
+				}
+				s.forward(pay, outpoint{tx.Hash, i})
+			}
+		}
+	}
+}
+
+//forward spends one output paying pay.InputAddr, sending it to
+//the destination minus mining and processing fees.
+func (s *Server) forward(pay *gobcy.PayFwd, op outpoint) {
+	o := s.utxos[op]
+	fee := int64(pay.MiningFees)
+	var process int64
+	if pay.ProcessAddr != "" {
+		process = pay.ProcessValue.Int64()
+		if pay.ProcessPercent > 0 {
+			process = int64(float64(o.value) * pay.ProcessPercent / 100)
+		}
+	}
+	rest := o.value - fee - process
+	if rest <= 0 {
+		return
+	}
+	var tx gobcy.TX
+	tx.Inputs = []gobcy.TXInput{{
+		PrevHash:    op.hash,
+		OutputIndex: op.n,
+		OutputValue: int(o.value),
+		Addresses:   []string{o.address},
+		Sequence:    4294967295,
+		ScriptType:  "pay-to-pubkey-hash",
+	}}
+	tx.Outputs = []gobcy.TXOutput{{
+		Value:      *big.NewInt(rest),
+		Addresses:  []string{pay.Destination},
+		ScriptType: s.scriptType(pay.Destination),
+		Script:     s.outputScript(pay.Destination),
+	}}
+	if process > 0 {
+		tx.Outputs = append(tx.Outputs, gobcy.TXOutput{
+			Value:      *big.NewInt(process),
+			Addresses:  []string{pay.ProcessAddr},
+			ScriptType: s.scriptType(pay.ProcessAddr),
+			Script:     s.outputScript(pay.ProcessAddr),
+		})
+	}
+	hash := s.addTX(tx, "")
+	pay.TXHistory = append(pay.TXHistory, hash)
+}
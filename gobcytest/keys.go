@@ -0,0 +1,100 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//versions holds the Base58Check version bytes of a chain.
+type versions struct {
+	pubKeyHash, scriptHash, wif byte
+}
+
+//oapVersion prefixes Open Assets addresses.
+const oapVersion = 0x17
+
+//keyPair is a key generated by the Server.
+type keyPair struct {
+	priv    *btcec.PrivateKey
+	address string
+	oap     string
+}
+
+//versions returns the version bytes of the Server's chain;
+//chains without them use the Bitcoin testnet ones.
+func (s *Server) versions() versions {
+	n, ok := gobcy.LookupNetwork(s.Coin, s.Chain)
+	if !ok || n.Account {
+		n, _ = gobcy.LookupNetwork("btc", "test3")
+	}
+	return versions{n.PubKeyHashVersion, n.ScriptHashVersion, n.WIFVersion}
+}
+
+//newKey generates and records a new key pair.
+func (s *Server) newKey() (*keyPair, gobcy.AddrKeychain) {
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		panic("gobcytest: generating key: " + err.Error())
+	}
+	pub := priv.PubKey().SerializeCompressed()
+	k := &keyPair{priv: priv, address: s.pubKeyAddr(pub)}
+	k.oap = base58.CheckEncode(hash20(pub), oapVersion)
+	s.keys[k.address] = k
+	wif := base58.CheckEncode(append(priv.Serialize(), 0x01), s.versions().wif)
+	return k, gobcy.AddrKeychain{
+		Address: k.address,
+		Private: hex.EncodeToString(priv.Serialize()),
+		Public:  hex.EncodeToString(pub),
+		Wif:     wif,
+	}
+}
+
+//pubKeyAddr returns the Server's address for a public key.
+func (s *Server) pubKeyAddr(pub []byte) string {
+	return base58.CheckEncode(hash20(pub), s.versions().pubKeyHash)
+}
+
+//scriptAddr returns the Server's P2SH address for a script.
+func (s *Server) scriptAddr(script []byte) string {
+	return base58.CheckEncode(hash20(script), s.versions().scriptHash)
+}
+
+//outputScript returns the hex-encoded output script paying addr,
+//or "" for addresses the Server can't decode.
+func (s *Server) outputScript(addr string) string {
+	payload, ver, err := base58.CheckDecode(addr)
+	if err != nil || len(payload) != 20 {
+		return ""
+	}
+	switch ver {
+	case s.versions().scriptHash:
+		return "a914" + hex.EncodeToString(payload) + "87"
+	default:
+		return "76a914" + hex.EncodeToString(payload) + "88ac"
+	}
+}
+
+//scriptType returns BlockCypher's script type for an address.
+func (s *Server) scriptType(addr string) string {
+	if _, ver, err := base58.CheckDecode(addr); err == nil && ver == s.versions().scriptHash {
+		return "pay-to-script-hash"
+	}
+	return "pay-to-pubkey-hash"
+}
+
+//validAddr reports whether addr is an address of the Server's chain.
+func (s *Server) validAddr(addr string) bool {
+	payload, ver, err := base58.CheckDecode(addr)
+	v := s.versions()
+	return err == nil && len(payload) == 20 && (ver == v.pubKeyHash || ver == v.scriptHash)
+}
+
+//hash20 is HASH160, RIPEMD-160 of SHA-256, so keys made
+//with gobcy.GenerateKeychain have the same addresses here.
+func hash20(data []byte) []byte {
+	return btcutil.Hash160(data)
+}
@@ -0,0 +1,435 @@
+package gobcytest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//blockInterval is how far the Server's clock moves per block.
+const blockInterval = 10 * time.Minute
+
+//block is a mined block.
+type block struct {
+	hash   string
+	height int
+	prev   string
+	time   time.Time
+	txids  []string
+}
+
+//outpoint identifies a transaction output.
+type outpoint struct {
+	hash string
+	n    int
+}
+
+//output is a transaction output, spent or not.
+type output struct {
+	value      int64
+	address    string
+	script     string
+	scriptType string
+	spentBy    string
+}
+
+//txRecord is a transaction known to the Server. Inputs and
+//outputs live in tx; block data and spent status are filled
+//in when the transaction is rendered.
+type txRecord struct {
+	tx        gobcy.TX
+	hex       string
+	height    int
+	blockHash string
+	received  time.Time
+	confirmed time.Time
+}
+
+//Fund sends amount satoshis from the faucet to addr in a new
+//unconfirmed transaction, returning its hash. Call Mine to
+//confirm it.
+func (s *Server) Fund(addr string, amount int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fundLocked(addr, amount)
+}
+
+//fundLocked is Fund with s.mu held.
+func (s *Server) fundLocked(addr string, amount int64) string {
+	var tx gobcy.TX
+	tx.Inputs = []gobcy.TXInput{{
+		OutputIndex: -1,
+		ScriptType:  "empty",
+		Sequence:    4294967295,
+		Addresses:   []string{},
+	}}
+	tx.Outputs = []gobcy.TXOutput{{
+		Value:      *big.NewInt(amount),
+		Addresses:  []string{addr},
+		ScriptType: "pay-to-pubkey-hash",
+		Script:     s.outputScript(addr),
+	}}
+	return s.addTX(tx, "")
+}
+
+//Mine mines n blocks, the first of which confirms every
+//transaction in the mempool, and returns them.
+func (s *Server) Mine(n int) []gobcy.Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mined := s.mineLocked(n)
+	blocks := make([]gobcy.Block, len(mined))
+	for i, b := range mined {
+		blocks[i] = s.renderBlock(b, 0, 20, "")
+	}
+	return blocks
+}
+
+//Height returns the height of the chain tip.
+func (s *Server) Height() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tip().height
+}
+
+//mineLocked is Mine with s.mu held.
+func (s *Server) mineLocked(n int) (mined []*block) {
+	for i := 0; i < n; i++ {
+		b := &block{height: 0}
+		if len(s.blocks) > 0 {
+			b.height = s.tip().height + 1
+			b.prev = s.tip().hash
+			s.now = s.now.Add(blockInterval)
+		}
+		b.time = s.now
+		for _, h := range s.order {
+			if rec := s.txs[h]; rec.height < 0 {
+				b.txids = append(b.txids, h)
+			}
+		}
+		b.hash = hashHex([]byte(strconv.Itoa(b.height) + b.prev + b.time.String() + joinIDs(b.txids)))
+		for _, h := range b.txids {
+			rec := s.txs[h]
+			rec.height = b.height
+			rec.blockHash = b.hash
+			rec.confirmed = b.time
+		}
+		s.blocks = append(s.blocks, b)
+		mined = append(mined, b)
+	}
+	return
+}
+
+//tip returns the last mined block.
+func (s *Server) tip() *block {
+	return s.blocks[len(s.blocks)-1]
+}
+
+//addTX records tx in the mempool, spending its inputs and
+//creating its outputs, and returns its hash. The caller has
+//checked that every input is unspent.
+func (s *Server) addTX(tx gobcy.TX, rawHex string) string {
+	tx.Hash = txHash(tx)
+	if _, ok := s.txs[tx.Hash]; ok {
+		//identical transaction, e.g. a second faucet call
+		//with the same address and amount
+		tx.LockTime = len(s.order)
+		tx.Hash = txHash(tx)
+	}
+	var in, out int64
+	for i, v := range tx.Inputs {
+		if v.PrevHash == "" {
+			continue
+		}
+		o := s.utxos[outpoint{v.PrevHash, v.OutputIndex}]
+		o.spentBy = tx.Hash
+		in += o.value
+		tx.Inputs[i].OutputValue = int(o.value)
+	}
+	for i, v := range tx.Outputs {
+		s.utxos[outpoint{tx.Hash, i}] = &output{
+			value:      v.Value.Int64(),
+			address:    firstAddr(v.Addresses),
+			script:     v.Script,
+			scriptType: v.ScriptType,
+		}
+		out += v.Value.Int64()
+	}
+	tx.Total = *big.NewInt(out)
+	if in > out {
+		tx.Fees = *big.NewInt(in - out)
+	}
+	tx.VinSize = len(tx.Inputs)
+	tx.VoutSize = len(tx.Outputs)
+	tx.Addresses = txAddrs(tx)
+	s.txs[tx.Hash] = &txRecord{tx: tx, hex: rawHex, height: -1, received: s.now}
+	s.order = append(s.order, tx.Hash)
+	s.forwardPayments(tx)
+	return tx.Hash
+}
+
+//renderTX returns the public view of a recorded transaction.
+func (s *Server) renderTX(rec *txRecord) gobcy.TX {
+	tx := rec.tx
+	tx.Inputs = append([]gobcy.TXInput(nil), rec.tx.Inputs...)
+	tx.Outputs = append([]gobcy.TXOutput(nil), rec.tx.Outputs...)
+	tx.Received = rec.received
+	tx.BlockHeight = rec.height
+	tx.Preference = "high"
+	tx.Ver = 1
+	if rec.height >= 0 {
+		tx.BlockHash = rec.blockHash
+		tx.Confirmed = rec.confirmed
+		tx.Confirmations = s.tip().height - rec.height + 1
+		tx.Confidence = 1
+	}
+	for i := range tx.Outputs {
+		if o := s.utxos[outpoint{tx.Hash, i}]; o != nil {
+			tx.Outputs[i].SpentBy = o.spentBy
+		}
+	}
+	for i, v := range tx.Inputs {
+		if prev := s.txs[v.PrevHash]; prev != nil {
+			if prev.height >= 0 {
+				tx.Inputs[i].Age = prev.height
+			} else {
+				tx.Inputs[i].Age = s.tip().height + 1
+			}
+		}
+	}
+	return tx
+}
+
+//renderBlock returns the public view of a block, with a
+//page of limit txids starting at txstart.
+func (s *Server) renderBlock(b *block, txstart, limit int, nextBase string) gobcy.Block {
+	bl := gobcy.Block{
+		Hash:         b.hash,
+		Height:       b.height,
+		Depth:        s.tip().height - b.height,
+		Chain:        chainName(s.Coin, s.Chain),
+		Ver:          1,
+		Time:         b.time,
+		ReceivedTime: b.time,
+		Bits:         486604799,
+		Nonce:        b.height,
+		NumTX:        len(b.txids),
+		PrevBlock:    b.prev,
+		MerkleRoot:   hashHex([]byte(joinIDs(b.txids))),
+		TXids:        []string{},
+	}
+	var total, fees int64
+	for _, h := range b.txids {
+		rec := s.txs[h]
+		total += rec.tx.Total.Int64()
+		fees += rec.tx.Fees.Int64()
+	}
+	bl.Total = *big.NewInt(total)
+	bl.Fees = *big.NewInt(fees)
+	if txstart < len(b.txids) {
+		end := txstart + limit
+		if end > len(b.txids) {
+			end = len(b.txids)
+		}
+		bl.TXids = append(bl.TXids, b.txids[txstart:end]...)
+		if end < len(b.txids) && nextBase != "" {
+			bl.NextTXs = nextBase + "/blocks/" + b.hash + "?txstart=" + strconv.Itoa(end) + "&limit=" + strconv.Itoa(limit)
+		}
+	}
+	return bl
+}
+
+//unspent returns the unspent outputs paying addr, oldest first.
+func (s *Server) unspent(addr string) (ops []outpoint) {
+	for _, h := range s.order {
+		for i, v := range s.txs[h].tx.Outputs {
+			op := outpoint{h, i}
+			if o := s.utxos[op]; o.spentBy == "" && firstAddr(v.Addresses) == addr {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return
+}
+
+//addrView holds everything the address endpoints report.
+type addrView struct {
+	addr                gobcy.Addr
+	confirmed, unconfed []gobcy.TXRef
+	txs                 []string
+}
+
+//viewAddrs builds the address view of a set of addresses,
+//newest transactions first, with the given minimum number
+//of confirmations.
+func (s *Server) viewAddrs(addrs []string, minConf int) (v addrView) {
+	set := make(map[string]bool)
+	for _, a := range addrs {
+		set[a] = true
+	}
+	tip := s.tip().height
+	var balance, unconfBal, received, sent int64
+	var refs []gobcy.TXRef
+	for _, h := range s.order {
+		rec := s.txs[h]
+		conf := 0
+		if rec.height >= 0 {
+			conf = tip - rec.height + 1
+		}
+		if conf < minConf {
+			continue
+		}
+		var txRefs []gobcy.TXRef
+		var delta int64
+		for i, in := range rec.tx.Inputs {
+			if in.PrevHash == "" || !set[firstAddr(in.Addresses)] {
+				continue
+			}
+			delta -= int64(in.OutputValue)
+			txRefs = append(txRefs, gobcy.TXRef{
+				Address:   firstAddr(in.Addresses),
+				TXInputN:  i,
+				TXOutputN: -1,
+				Value:     *big.NewInt(int64(in.OutputValue)),
+				Script:    in.Script,
+			})
+		}
+		for i, out := range rec.tx.Outputs {
+			if !set[firstAddr(out.Addresses)] {
+				continue
+			}
+			o := s.utxos[outpoint{h, i}]
+			delta += o.value
+			txRefs = append(txRefs, gobcy.TXRef{
+				Address:   o.address,
+				TXInputN:  -1,
+				TXOutputN: i,
+				Value:     *big.NewInt(o.value),
+				Spent:     o.spentBy != "",
+				SpentBy:   o.spentBy,
+				Script:    o.script,
+			})
+		}
+		if len(txRefs) == 0 {
+			continue
+		}
+		if rec.height >= 0 {
+			balance += delta
+		} else {
+			unconfBal += delta
+		}
+		for i := range txRefs {
+			r := &txRefs[i]
+			r.TXHash = h
+			r.BlockHeight = rec.height
+			r.Confirmations = conf
+			r.Pref = "high"
+			r.Received = rec.received
+			r.Confirmed = rec.confirmed
+			r.RefBalance = int(balance + unconfBal)
+			if r.TXOutputN >= 0 {
+				received += r.Value.Int64()
+			} else {
+				sent += r.Value.Int64()
+			}
+		}
+		refs = append(refs, txRefs...)
+		v.txs = append(v.txs, h)
+		if rec.height >= 0 {
+			v.addr.NumTX++
+		} else {
+			v.addr.UnconfirmedNumTX++
+		}
+	}
+	//newest first, like BlockCypher; mining keeps s.order
+	//sorted by height already
+	for i := len(refs) - 1; i >= 0; i-- {
+		if refs[i].BlockHeight < 0 {
+			v.unconfed = append(v.unconfed, refs[i])
+		} else {
+			v.confirmed = append(v.confirmed, refs[i])
+		}
+	}
+	for i, j := 0, len(v.txs)-1; i < j; i, j = i+1, j-1 {
+		v.txs[i], v.txs[j] = v.txs[j], v.txs[i]
+	}
+	v.addr.Balance = *big.NewInt(balance)
+	v.addr.UnconfirmedBalance = *big.NewInt(unconfBal)
+	v.addr.FinalBalance = *big.NewInt(balance + unconfBal)
+	v.addr.TotalReceived = *big.NewInt(received)
+	v.addr.TotalSent = *big.NewInt(sent)
+	v.addr.FinalNumTX = v.addr.NumTX + v.addr.UnconfirmedNumTX
+	return
+}
+
+//txHash derives a transaction hash from its contents.
+func txHash(tx gobcy.TX) string {
+	tx.Hash = ""
+	data, _ := json.Marshal(struct {
+		In   []gobcy.TXInput
+		Out  []gobcy.TXOutput
+		Lock int
+	}{tx.Inputs, tx.Outputs, tx.LockTime})
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return hex.EncodeToString(second[:])
+}
+
+//hashHex returns the hex-encoded SHA-256 of data.
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+//joinIDs concatenates ids for hashing.
+func joinIDs(ids []string) (all string) {
+	for _, v := range ids {
+		all += v
+	}
+	return
+}
+
+//firstAddr returns the first address of a list, if any.
+func firstAddr(addrs []string) string {
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[0]
+}
+
+//txAddrs lists the distinct addresses a transaction touches.
+func txAddrs(tx gobcy.TX) (addrs []string) {
+	seen := make(map[string]bool)
+	add := func(list []string) {
+		for _, a := range list {
+			if !seen[a] {
+				seen[a] = true
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	for _, v := range tx.Inputs {
+		add(v.Addresses)
+	}
+	for _, v := range tx.Outputs {
+		add(v.Addresses)
+	}
+	return
+}
+
+//chainName returns BlockCypher's name for a coin/chain,
+//e.g. "BTC.main".
+func chainName(coin, chain string) string {
+	upper := []byte(coin)
+	for i, c := range upper {
+		if c >= 'a' && c <= 'z' {
+			upper[i] = c - 'a' + 'A'
+		}
+	}
+	return string(upper) + "." + chain
+}
@@ -0,0 +1,91 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestRateLimiter(t *testing.T) {
+	c := context.Background()
+	_, bcy, _ := setup(t)
+	//waiting for the budget to refill, then failing fast
+	bcy.Limiter = gobcy.NewRateLimiter(gobcy.Usage{PerSec: 2})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := bcy.GetChain(c); err != nil {
+			t.Fatal("GetChain error encountered: ", err)
+		}
+	}
+	if waited := time.Since(start); waited < 400*time.Millisecond {
+		t.Errorf("Expected the third call of 2/s to wait, took %v", waited)
+	}
+	short, cancel := context.WithTimeout(c, 10*time.Millisecond)
+	defer cancel()
+	var lim *gobcy.LimitError
+	if _, err := bcy.GetChain(short); !errors.As(err, &lim) || lim.Budget != "api/second" {
+		t.Errorf("Expected a LimitError past the deadline, got %v", err)
+	}
+	bcy.Limiter.FailFast = true
+	start = time.Now()
+	if _, err := bcy.GetChain(c); !errors.Is(err, gobcy.ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited when failing fast, got %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("FailFast waited for the budget")
+	}
+	//only creating hooks draws from the hooks budget
+	bcy.Limiter = gobcy.NewRateLimiter(gobcy.Usage{HooksPerHour: 1})
+	bcy.Limiter.FailFast = true
+	hook, err := bcy.CreateHook(c, gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callbacks"})
+	if err != nil {
+		t.Fatal("CreateHook error encountered: ", err)
+	}
+	if _, err = bcy.GetHook(c, hook.ID); err != nil {
+		t.Error("GetHook error encountered: ", err)
+	}
+	if _, err = bcy.ListHooks(c); err != nil {
+		t.Error("ListHooks error encountered: ", err)
+	}
+	_, err = bcy.CreateHook(c, gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callbacks"})
+	if !errors.As(err, &lim) || lim.Budget != "hooks/hour" {
+		t.Errorf("Expected the hooks/hour budget to be exhausted, got %v", err)
+	}
+	if err = bcy.DeleteHook(c, hook.ID); err != nil {
+		t.Error("DeleteHook error encountered: ", err)
+	}
+	//seeding deducts the hits already counted
+	bcy.Limiter.Seed(gobcy.TokenUsage{Limits: gobcy.Usage{PerHour: 3}, Hits: gobcy.Usage{PerHour: 2}})
+	if _, err = bcy.GetChain(c); err != nil {
+		t.Error("GetChain error encountered: ", err)
+	}
+	if _, err = bcy.GetChain(c); !errors.As(err, &lim) || lim.Budget != "api/hour" {
+		t.Errorf("Expected a seeded budget of 1 call, got %v", err)
+	}
+	//the server allows 200 calls an hour
+	bcy.Limiter = nil
+	usage, err := bcy.CheckUsage(c)
+	if err != nil {
+		t.Fatal("CheckUsage error encountered: ", err)
+	}
+	for i := usage.Hits.PerHour; i < usage.Limits.PerHour-1; i++ {
+		if _, err = bcy.GetChain(c); err != nil {
+			t.Fatal("GetChain error encountered: ", err)
+		}
+	}
+	limiter := gobcy.NewRateLimiter(gobcy.Usage{})
+	limiter.FailFast = true
+	if err = limiter.Refresh(c, &bcy); err != nil {
+		t.Fatal("Refresh error encountered: ", err)
+	}
+	bcy.Limiter = limiter
+	if _, err = bcy.GetChain(c); err != nil {
+		t.Error("GetChain error encountered: ", err)
+	}
+	if _, err = bcy.GetChain(c); !errors.As(err, &lim) || lim.Budget != "api/hour" {
+		t.Errorf("Expected Refresh to leave 1 call of %v, got %v", usage.Limits.PerHour, err)
+	}
+}
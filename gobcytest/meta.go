@@ -0,0 +1,265 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"math/big"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//metaKey returns the key under which metadata of the call's
+//object is stored; private metadata is scoped to the token.
+func metaKey(c *call) string {
+	key := c.parts[0] + "/" + c.parts[1]
+	if c.boolParam("private") {
+		key += "/private/" + c.token
+	}
+	return key
+}
+
+//getMeta answers GET /{addrs,txs,blocks}/{hash}/meta.
+func (s *Server) getMeta(c *call) (interface{}, *apiError) {
+	meta := map[string]string{}
+	for k, v := range s.meta[metaKey(c)] {
+		meta[k] = v
+	}
+	return meta, nil
+}
+
+//putMeta answers PUT /{addrs,txs,blocks}/{hash}/meta. Public
+//metadata is immutable: existing keys can't be overwritten.
+func (s *Server) putMeta(c *call) (interface{}, *apiError) {
+	var req map[string]string
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	key := metaKey(c)
+	if s.meta[key] == nil {
+		s.meta[key] = make(map[string]string)
+	}
+	for k, v := range req {
+		if _, ok := s.meta[key][k]; ok && !c.boolParam("private") {
+			return nil, errorf(http.StatusConflict, "Public metadata key "+k+" already set; public metadata is immutable.")
+		}
+		s.meta[key][k] = v
+	}
+	return nil, nil
+}
+
+//deleteMeta answers DELETE /{addrs,txs,blocks}/{hash}/meta,
+//removing all private metadata of the token.
+func (s *Server) deleteMeta(c *call) (interface{}, *apiError) {
+	c.params["private"] = "true"
+	delete(s.meta, metaKey(c))
+	return nil, nil
+}
+
+//asset is an Open Asset issued on the Server.
+type asset struct {
+	id       string
+	txs      []string
+	oaptxs   map[string]gobcy.OAPTX
+	balances map[string]int64
+}
+
+//assetDust is the amount moved on the underlying chain by
+//issuance and transfer transactions.
+const assetDust = 600
+
+//genAssetAddr answers POST /oap/addrs.
+func (s *Server) genAssetAddr(c *call) (interface{}, *apiError) {
+	k, keys := s.newKey()
+	keys.OriginalAddress = keys.Address
+	keys.OAPAddress = k.oap
+	keys.Address = ""
+	return keys, nil
+}
+
+//keyByPrivate finds a Server-generated key by its hex private key.
+func (s *Server) keyByPrivate(priv string) *keyPair {
+	for _, k := range s.keys {
+		if hex.EncodeToString(k.priv.Serialize()) == priv {
+			return k
+		}
+	}
+	return nil
+}
+
+//keyByOAP finds a Server-generated key by its Open Assets address.
+func (s *Server) keyByOAP(oap string) *keyPair {
+	for _, k := range s.keys {
+		if k.oap == oap {
+			return k
+		}
+	}
+	return nil
+}
+
+//moveAsset records an issuance (from == nil) or transfer of
+//an asset, backed by a dust transaction on the underlying chain
+//paid for by the sender.
+func (s *Server) moveAsset(a *asset, from *keyPair, issue gobcy.OAPIssue) (gobcy.OAPTX, *apiError) {
+	to := s.keyByOAP(issue.ToAddr)
+	if to == nil {
+		return gobcy.OAPTX{}, errorf(http.StatusBadRequest, "Unknown to_address: "+issue.ToAddr+".")
+	}
+	amount := issue.Amount.Int64()
+	if amount <= 0 {
+		return gobcy.OAPTX{}, errorf(http.StatusBadRequest, "Amount must be positive.")
+	}
+	if from.oap != "" && a.balances[from.oap] < amount && len(a.txs) > 0 {
+		return gobcy.OAPTX{}, errorf(http.StatusBadRequest, "Not enough assets held by "+from.oap+".")
+	}
+	var in int64
+	var tx gobcy.TX
+	for _, op := range s.unspent(from.address) {
+		if in >= assetDust+s.Fee {
+			break
+		}
+		o := s.utxos[op]
+		in += o.value
+		tx.Inputs = append(tx.Inputs, gobcy.TXInput{
+			PrevHash:    op.hash,
+			OutputIndex: op.n,
+			Addresses:   []string{from.address},
+			Sequence:    4294967295,
+			ScriptType:  "pay-to-pubkey-hash",
+		})
+	}
+	if in < assetDust+s.Fee {
+		e := errorf(http.StatusBadRequest, "")
+		e.errs = []string{"Not enough funds in " + from.address + " to pay for the asset transaction."}
+		return gobcy.OAPTX{}, e
+	}
+	tx.Outputs = []gobcy.TXOutput{{
+		Value:      *big.NewInt(assetDust),
+		Addresses:  []string{to.address},
+		ScriptType: "pay-to-pubkey-hash",
+		Script:     s.outputScript(to.address),
+	}}
+	if change := in - assetDust - s.Fee; change > 0 {
+		tx.Outputs = append(tx.Outputs, gobcy.TXOutput{
+			Value:      *big.NewInt(change),
+			Addresses:  []string{from.address},
+			ScriptType: "pay-to-pubkey-hash",
+			Script:     s.outputScript(from.address),
+		})
+	}
+	hash := s.addTX(tx, "")
+	issued := len(a.txs) == 0
+	if !issued {
+		a.balances[from.oap] -= amount
+	}
+	a.balances[issue.ToAddr] += amount
+	oaptx := gobcy.OAPTX{
+		Ver:      1,
+		AssetID:  a.id,
+		Hash:     hash,
+		Received: s.now,
+		Metadata: issue.Metadata,
+	}
+	oaptx.Outputs = append(oaptx.Outputs, struct {
+		OAPAddress      string  `json:"address"`
+		Value           big.Int `json:"value"`
+		OrigOutputIndex int     `json:"original_output_index"`
+	}{issue.ToAddr, *big.NewInt(amount), 0})
+	if !issued {
+		oaptx.Inputs = append(oaptx.Inputs, struct {
+			PrevHash    string  `json:"prev_hash"`
+			OutputIndex int     `json:"output_index"`
+			OAPAddress  string  `json:"address"`
+			OutputValue big.Int `json:"output_value"`
+		}{tx.Inputs[0].PrevHash, tx.Inputs[0].OutputIndex, from.oap, *big.NewInt(amount)})
+	}
+	a.txs = append(a.txs, hash)
+	a.oaptxs[hash] = oaptx
+	return oaptx, nil
+}
+
+//issueAsset answers POST /oap/issue.
+func (s *Server) issueAsset(c *call) (interface{}, *apiError) {
+	var issue gobcy.OAPIssue
+	if err := c.decode(&issue); err != nil {
+		return nil, err
+	}
+	from := s.keyByPrivate(issue.Priv)
+	if from == nil {
+		return nil, errorf(http.StatusBadRequest, "Unknown from_private key.")
+	}
+	a := &asset{oaptxs: make(map[string]gobcy.OAPTX), balances: make(map[string]int64)}
+	a.id = base58.CheckEncode(hash20([]byte(from.address+s.newID("asset"))), oapVersion)
+	oaptx, err := s.moveAsset(a, &keyPair{priv: from.priv, address: from.address}, issue)
+	if err != nil {
+		return nil, err
+	}
+	s.assets[a.id] = a
+	return oaptx, nil
+}
+
+//lookupAsset returns the asset named in the call's path.
+func (s *Server) lookupAsset(c *call) (*asset, *apiError) {
+	a := s.assets[c.parts[1]]
+	if a == nil {
+		return nil, errorf(http.StatusNotFound, "Asset "+c.parts[1]+" not found.")
+	}
+	return a, nil
+}
+
+//transferAsset answers POST /oap/{assetid}/transfer.
+func (s *Server) transferAsset(c *call) (interface{}, *apiError) {
+	a, err := s.lookupAsset(c)
+	if err != nil {
+		return nil, err
+	}
+	var issue gobcy.OAPIssue
+	if err := c.decode(&issue); err != nil {
+		return nil, err
+	}
+	from := s.keyByPrivate(issue.Priv)
+	if from == nil {
+		return nil, errorf(http.StatusBadRequest, "Unknown from_private key.")
+	}
+	return s.moveAsset(a, from, issue)
+}
+
+//listAssetTXs answers GET /oap/{assetid}/txs.
+func (s *Server) listAssetTXs(c *call) (interface{}, *apiError) {
+	a, err := s.lookupAsset(c)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, a.txs...), nil
+}
+
+//getAssetTX answers GET /oap/{assetid}/txs/{hash}.
+func (s *Server) getAssetTX(c *call) (interface{}, *apiError) {
+	a, err := s.lookupAsset(c)
+	if err != nil {
+		return nil, err
+	}
+	oaptx, ok := a.oaptxs[c.parts[3]]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Asset transaction "+c.parts[3]+" not found.")
+	}
+	if rec := s.txs[oaptx.Hash]; rec != nil && rec.height >= 0 {
+		oaptx.Confirmed = rec.confirmed
+	}
+	return oaptx, nil
+}
+
+//getAssetAddr answers GET /oap/{assetid}/addrs/{oapaddr}, with
+//balances counted in units of the asset.
+func (s *Server) getAssetAddr(c *call) (interface{}, *apiError) {
+	a, err := s.lookupAsset(c)
+	if err != nil {
+		return nil, err
+	}
+	bal := a.balances[c.parts[3]]
+	return gobcy.Addr{
+		Address:      c.parts[3],
+		Balance:      *big.NewInt(bal),
+		FinalBalance: *big.NewInt(bal),
+	}, nil
+}
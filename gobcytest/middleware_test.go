@@ -0,0 +1,89 @@
+package gobcytest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestMiddleware(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	var calls []gobcy.Call
+	bcy.Middleware = []gobcy.Middleware{func(next gobcy.Handler) gobcy.Handler {
+		return func(c context.Context, call *gobcy.Call) error {
+			err := next(c, call)
+			calls = append(calls, *call)
+			return err
+		}
+	}}
+	bcy.Retry = &gobcy.RetryPolicy{MaxAttempts: 2, BaseDelay: 1}
+	srv.FailNext(502, 1)
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	if _, err := bcy.CheckUsage(c); err != nil {
+		t.Fatal("CheckUsage error encountered: ", err)
+	}
+	if len(calls) != 3 || calls[0].StatusCode != 502 || calls[1].Attempt != 2 || calls[1].StatusCode != 200 {
+		t.Fatalf("Unexpected calls seen by middleware: %+v", calls)
+	}
+	for _, v := range calls {
+		if strings.Contains(v.URL+v.Endpoint+v.Params.Encode(), bcy.Token) {
+			t.Errorf("Middleware saw the token in %+v", v)
+		}
+	}
+}
+
+func TestLogMiddleware(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	bcy.Middleware = []gobcy.Middleware{gobcy.LogMiddleware(logger)}
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	srv.FailNext(503, 1)
+	if _, err := bcy.GetChain(c); err == nil {
+		t.Fatal("Expected error from a 503, did not receive one")
+	}
+	if _, err := bcy.GetTX(c, "nope", nil); !errors.Is(err, gobcy.ErrNotFound) {
+		t.Fatal("Expected ErrNotFound for unknown TX, got: ", err)
+	}
+	type record struct {
+		Level, Msg, Method, Endpoint, Error string
+		Attempt, Status                     int
+		Latency                             int64
+	}
+	var records []record
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Unexpected log line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 log records, got %v", buf.String())
+	}
+	ok, failed, missing := records[0], records[1], records[2]
+	if ok.Level != "DEBUG" || ok.Msg != "gobcy request" || ok.Method != "GET" || ok.Endpoint != "/v1/bcy/test" || ok.Attempt != 1 || ok.Status != 200 || ok.Latency <= 0 || ok.Error != "" {
+		t.Errorf("Unexpected record of a successful call: %+v", ok)
+	}
+	if failed.Level != "WARN" || failed.Status != 503 || !strings.Contains(failed.Error, "503") {
+		t.Errorf("Unexpected record of a failed call: %+v", failed)
+	}
+	if missing.Level != "WARN" || missing.Status != 404 || missing.Endpoint != "/v1/bcy/test/txs/nope" {
+		t.Errorf("Unexpected record of a missing TX: %+v", missing)
+	}
+	if strings.Contains(buf.String(), bcy.Token) {
+		t.Error("The log leaks the token: ", buf.String())
+	}
+}
@@ -0,0 +1,139 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThePiachu/gobcy/v2"
+	"github.com/ThePiachu/gobcy/v2/gobcytest"
+)
+
+func TestRedaction(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	srv.Close()
+	_, err := bcy.GetChain(c)
+	if err == nil {
+		t.Fatal("Expected error from a closed server, did not receive one")
+	}
+	if strings.Contains(err.Error(), bcy.Token) {
+		t.Error("Transport error leaks the token: ", err)
+	}
+	other := gobcytest.NewServer("bcy", "test")
+	defer other.Close()
+	other.Tokens = []string{"only-this-one"}
+	bcy = other.API("test-token")
+	if _, err = bcy.CheckUsage(c); !errors.Is(err, gobcy.ErrUnauthorized) || strings.Contains(err.Error(), bcy.Token) {
+		t.Error("Expected a redacted error from CheckUsage, got: ", err)
+	}
+}
+
+
+func TestRedactMessages(t *testing.T) {
+	c := context.Background()
+	token := "secret-token-1234567890"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, ";") {
+			w.Write([]byte(`[{"error": "Token ` + token + ` can't read aa"}, {"error": "Token ` + token + ` can't read bb"}]`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "Token ` + token + ` is invalid", "errors": [{"error": "token=` + token + `"}]}`))
+	}))
+	defer srv.Close()
+	bcy := gobcy.NewAPI(token, "btc", "main", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	_, err := bcy.GetChain(c)
+	var apiErr *gobcy.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, gobcy.ErrUnauthorized) {
+		t.Fatal("Expected an *APIError, got: ", err)
+	}
+	if strings.Contains(err.Error(), token) || apiErr.Message != "Token REDACTED is invalid" || len(apiErr.Messages) != 1 || apiErr.Messages[0] != "token=REDACTED" {
+		t.Errorf("Expected the echoed token to be redacted, got %q, %+v", err, apiErr)
+	}
+	txs, err := bcy.GetTXs(c, []string{"aa", "bb"}, nil)
+	if err != nil {
+		t.Fatal("GetTXs error encountered: ", err)
+	}
+	for _, v := range txs {
+		if v.Err == nil || strings.Contains(v.Err.Error(), token) {
+			t.Errorf("Expected a redacted batch item error, got %v", v.Err)
+		}
+	}
+}
+
+func TestRedactShortToken(t *testing.T) {
+	c := context.Background()
+	srv, _, _ := setup(t)
+	bcy := srv.API("x")
+	var calls []gobcy.Call
+	bcy.Middleware = []gobcy.Middleware{func(next gobcy.Handler) gobcy.Handler {
+		return func(c context.Context, call *gobcy.Call) error {
+			err := next(c, call)
+			calls = append(calls, *call)
+			return err
+		}
+	}}
+	_, err := bcy.PushTX(c, "00")
+	if err == nil || !strings.Contains(err.Error(), "/v1/bcy/test/txs/push") {
+		t.Errorf("Expected an error about /txs/push, got %v", err)
+	}
+	if _, err = bcy.CheckUsage(c); err != nil {
+		t.Fatal("CheckUsage error encountered: ", err)
+	}
+	if len(calls) != 2 || calls[0].Endpoint != "/v1/bcy/test/txs/push" || calls[1].Endpoint != "/v1/tokens/REDACTED" {
+		t.Fatalf("Unexpected calls seen by middleware: %+v", calls)
+	}
+	for _, v := range calls {
+		if v.Params.Has("token") || strings.Contains(v.URL, "token=") || strings.Contains(v.URL, "/tokens/x") {
+			t.Errorf("Middleware saw the token in %+v", v)
+		}
+	}
+}
+
+func TestTokenHeader(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	bcy.TokenHeader = "X-Token"
+	var queries, headers []string
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		headers = append(headers, r.Header.Get("X-Token"))
+		//the fake only reads the token from the query
+		q := r.URL.Query()
+		q.Set("token", r.Header.Get("X-Token"))
+		r.URL.RawQuery = q.Encode()
+		handler.ServeHTTP(w, r)
+	})
+	var seen []gobcy.Call
+	bcy.Middleware = []gobcy.Middleware{func(next gobcy.Handler) gobcy.Handler {
+		return func(c context.Context, call *gobcy.Call) error {
+			seen = append(seen, *call)
+			return next(c, call)
+		}
+	}}
+	if _, err := bcy.GetAddrBal(c, "nope", map[string]string{"omitWalletAddresses": "true"}); err == nil {
+		t.Error("Expected error for an invalid address, did not receive one")
+	}
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	for i := range queries {
+		if strings.Contains(queries[i], "token") || headers[i] != bcy.Token {
+			t.Errorf("Expected the token in the header only, got query %q and header %q", queries[i], headers[i])
+		}
+	}
+	if len(queries) != 2 || queries[0] != "omitWalletAddresses=true" {
+		t.Errorf("Unexpected queries %v", queries)
+	}
+	for _, v := range seen {
+		if strings.Contains(v.URL+v.Params.Encode(), bcy.Token) {
+			t.Errorf("Middleware saw the token in %+v", v)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestRetry(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	srv.FailNext(503, 2)
+	if _, err := bcy.GetChain(c); err == nil {
+		t.Error("Expected 503 without a retry policy, did not receive one")
+	}
+	bcy.Retry = &gobcy.RetryPolicy{MaxAttempts: 3, BaseDelay: 1}
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Error("GetChain with retries error encountered: ", err)
+	}
+	//count the calls per endpoint, and rate limit the
+	//next ones with a Retry-After
+	var mu sync.Mutex
+	hits := map[string]int{}
+	limited, retryAfter := 0, ""
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.Method+" "+r.URL.Path[strings.LastIndex(r.URL.Path, "/"):]]++
+		limit := limited > 0
+		if limit {
+			limited--
+		}
+		mu.Unlock()
+		if limit {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": "Limits reached."}`))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	limited, retryAfter = 1, "1"
+	start := time.Now()
+	if _, err := bcy.GetChain(c); err != nil {
+		t.Error("GetChain after a 429 error encountered: ", err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("Expected the retry to wait for Retry-After, took %v", waited)
+	}
+	//a Retry-After past the deadline isn't waited for
+	limited, retryAfter = 1, "60"
+	short, cancel := context.WithTimeout(c, time.Second)
+	defer cancel()
+	start = time.Now()
+	if _, err := bcy.GetChain(short); !errors.Is(err, gobcy.ErrRateLimited) {
+		t.Error("Expected ErrRateLimited past the deadline, got ", err)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("Expected the deadline to cut the backoff short, took %v", waited)
+	}
+	limited = 0
+	//creating a hook isn't repeated after a 5xx...
+	srv.FailNext(503, 1)
+	if _, err := bcy.CreateHook(c, gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callbacks"}); err == nil {
+		t.Error("Expected CreateHook to fail without a retry after a 503")
+	}
+	if hits["POST /hooks"] != 1 {
+		t.Errorf("Expected CreateHook to be sent once, got %v", hits["POST /hooks"])
+	}
+	if hooks, err := bcy.ListHooks(c); err != nil || len(hooks) != 0 {
+		t.Errorf("ListHooks returned %v, %v; expected none", hooks, err)
+	}
+	//...but pushing a transaction is: the second attempt
+	//reaches the endpoint, which rejects the bad hex
+	srv.FailNext(503, 1)
+	if _, err := bcy.PushTX(c, "00"); err == nil || errors.Is(err, gobcy.ErrRateLimited) {
+		t.Error("Expected PushTX to be rejected for its hex, got ", err)
+	}
+	if hits["POST /push"] != 2 {
+		t.Errorf("Expected PushTX to be sent twice, got %v", hits["POST /push"])
+	}
+}
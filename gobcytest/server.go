@@ -0,0 +1,379 @@
+//Package gobcytest provides an in-memory stand-in for the
+//BlockCypher API, so code built on gobcy can be tested without
+//a token or network access.
+//
+//A Server keeps a small UTXO ledger for a single coin/chain.
+//Addresses are funded through Fund (or the faucet endpoint),
+//transactions built with NewTX/Sign/SendTX spend them, and Mine
+//confirms everything waiting in the mempool:
+//	srv := gobcytest.NewServer("bcy", "test")
+//	defer srv.Close()
+//	bc := srv.API("test-token")
+//	keys, _ := bc.GenAddrKeychain(c)
+//	srv.Fund(keys.Address, 100000)
+//	srv.Mine(1)
+//	addr, _ := bc.GetAddrBal(c, keys.Address, nil)
+//
+//Addresses handed out by the server are real P2PKH addresses of
+//the chain (Base58Check of the HASH160 of the public key), so keys
+//made with gobcy.GenerateKeychain can be funded and spent as well.
+package gobcytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//Server is a fake BlockCypher API serving one coin/chain.
+//It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	Coin, Chain string
+	//Fee is the mining fee charged by transactions built
+	//through /txs/new and payment forwards, in satoshis.
+	Fee int64
+	//Tokens, if not empty, lists the only tokens accepted;
+	//any other token (or none) gets an HTTP 401.
+	Tokens []string
+
+	mu       sync.Mutex
+	now      time.Time
+	blocks   []*block
+	txs      map[string]*txRecord
+	order    []string
+	utxos    map[outpoint]*output
+	keys     map[string]*keyPair
+	wallets  map[string]map[string]*wallet
+	hd       map[string]map[string]*hdWallet
+	hooks    map[string]map[string]*gobcy.Hook
+	payfwds  map[string]map[string]*gobcy.PayFwd
+	meta     map[string]map[string]string
+	assets   map[string]*asset
+	hits     map[string]int
+	nextID   int
+	handlers []route
+	failures []int
+}
+
+//NewServer starts a Server for the given coin/chain, with
+//a genesis block already mined. Close it when done.
+func NewServer(coin, chain string) *Server {
+	s := &Server{
+		Coin:    coin,
+		Chain:   chain,
+		Fee:     10000,
+		now:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		txs:     make(map[string]*txRecord),
+		utxos:   make(map[outpoint]*output),
+		keys:    make(map[string]*keyPair),
+		wallets: make(map[string]map[string]*wallet),
+		hd:      make(map[string]map[string]*hdWallet),
+		hooks:   make(map[string]map[string]*gobcy.Hook),
+		payfwds: make(map[string]map[string]*gobcy.PayFwd),
+		meta:    make(map[string]map[string]string),
+		assets:  make(map[string]*asset),
+		hits:    make(map[string]int),
+	}
+	s.routes()
+	s.mineLocked(1)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+//API returns a gobcy.API for token that talks to this Server.
+func (s *Server) API(token string) gobcy.API {
+	api := gobcy.NewAPI(token, s.Coin, s.Chain, s.Client())
+	api.BaseURL = s.URL + "/v1/"
+	return api
+}
+
+//FailNext makes the next n requests fail with the given HTTP
+//status, before reaching any endpoint. Useful to exercise retry
+//and error handling.
+func (s *Server) FailNext(status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ; n > 0; n-- {
+		s.failures = append(s.failures, status)
+	}
+}
+
+//apiError describes an error response in BlockCypher's format.
+type apiError struct {
+	status int
+	msg    string
+	errs   []string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+//errorf builds an apiError with a top-level message.
+func errorf(status int, msg string) *apiError {
+	return &apiError{status: status, msg: msg}
+}
+
+//call is the parsed form of an incoming request.
+type call struct {
+	r      *http.Request
+	token  string
+	parts  []string
+	params map[string]string
+}
+
+//param returns the named query parameter.
+func (c *call) param(name string) string {
+	return c.params[name]
+}
+
+//intParam returns the named query parameter as an
+//integer, or def if it is missing or malformed.
+func (c *call) intParam(name string, def int) int {
+	if v, err := strconv.Atoi(c.params[name]); err == nil {
+		return v
+	}
+	return def
+}
+
+//boolParam reports whether the named query parameter is "true".
+func (c *call) boolParam(name string) bool {
+	return c.params[name] == "true"
+}
+
+//decode reads the JSON request body into v.
+func (c *call) decode(v interface{}) *apiError {
+	if err := json.NewDecoder(c.r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "Couldn't deserialize request: "+err.Error())
+	}
+	return nil
+}
+
+//handler serves a call, returning the value to encode as
+//JSON (nil for an empty response) or an error.
+type handler func(c *call) (interface{}, *apiError)
+
+//route maps a method and a path pattern to a handler. Pattern
+//segments starting with ":" match any single path segment.
+type route struct {
+	method  string
+	pattern []string
+	status  int
+	h       handler
+}
+
+//handle registers h for method and pattern, relative to
+///v1/{coin}/{chain}. status is the HTTP status of success.
+func (s *Server) handle(method, pattern string, status int, h handler) {
+	var segments []string
+	if pattern != "" {
+		segments = strings.Split(pattern, "/")
+	}
+	s.handlers = append(s.handlers, route{method, segments, status, h})
+}
+
+//match finds the route for method and path segments,
+//preferring the one with the most literal segments.
+func (s *Server) match(method string, parts []string) (rt *route, pathFound bool) {
+	best := -1
+	for i := range s.handlers {
+		r := &s.handlers[i]
+		if len(r.pattern) != len(parts) {
+			continue
+		}
+		literals := 0
+		ok := true
+		for j, p := range r.pattern {
+			if strings.HasPrefix(p, ":") {
+				continue
+			}
+			if p != parts[j] {
+				ok = false
+				break
+			}
+			literals++
+		}
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if r.method == method && literals > best {
+			rt, best = r, literals
+		}
+	}
+	return
+}
+
+//serveHTTP dispatches an API request to its handler.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c := &call{r: r, token: r.URL.Query().Get("token"), params: make(map[string]string)}
+	for k := range r.URL.Query() {
+		c.params[k] = r.URL.Query().Get(k)
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, errorf(status, http.StatusText(status)))
+		return
+	}
+	if len(s.Tokens) > 0 && !contains(s.Tokens, c.token) {
+		writeError(w, errorf(http.StatusUnauthorized, "Unauthorized: token is missing or invalid."))
+		return
+	}
+	if strings.HasPrefix(path, "v1/tokens/") {
+		s.serveUsage(w, strings.TrimPrefix(path, "v1/tokens/"))
+		return
+	}
+	prefix := "v1/" + s.Coin + "/" + s.Chain
+	if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+		writeError(w, errorf(http.StatusNotFound, "Unknown coin/chain or endpoint: /"+path))
+		return
+	}
+	s.hits[c.token]++
+	rest := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if rest != "" {
+		c.parts = strings.Split(rest, "/")
+	}
+	rt, pathFound := s.match(r.Method, c.parts)
+	if rt == nil {
+		if pathFound {
+			writeError(w, errorf(http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed on /"+path))
+		} else {
+			writeError(w, errorf(http.StatusNotFound, "Unknown endpoint: /"+path))
+		}
+		return
+	}
+	v, apiErr := rt.h(c)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if v == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rt.status)
+	json.NewEncoder(w).Encode(addressable(v))
+}
+
+//addressable returns a pointer to a copy of v, so that fields
+//like big.Int, whose MarshalJSON has a pointer receiver, are
+//encoded as numbers rather than "{}".
+func addressable(v interface{}) interface{} {
+	p := reflect.New(reflect.TypeOf(v))
+	p.Elem().Set(reflect.ValueOf(v))
+	return p.Interface()
+}
+
+//serveUsage answers the token usage endpoint.
+func (s *Server) serveUsage(w http.ResponseWriter, token string) {
+	usage := gobcy.TokenUsage{
+		Limits: gobcy.Usage{PerSec: 3, PerHour: 200, PerDay: 2000, HooksPerHour: 200, ConfPerHour: 15, Hooks: 200, PayFwds: 200},
+		Hits:   gobcy.Usage{PerHour: s.hits[token], PerDay: s.hits[token], Hooks: len(s.hooks[token]), PayFwds: len(s.payfwds[token])},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+//writeError writes e in BlockCypher's error format.
+func writeError(w http.ResponseWriter, e *apiError) {
+	body := struct {
+		Err    string `json:"error,omitempty"`
+		Errors []struct {
+			Err string `json:"error"`
+		} `json:"errors,omitempty"`
+	}{Err: e.msg}
+	for _, v := range e.errs {
+		body.Errors = append(body.Errors, struct {
+			Err string `json:"error"`
+		}{v})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	json.NewEncoder(w).Encode(body)
+}
+
+//routes registers every supported endpoint.
+func (s *Server) routes() {
+	s.handle("GET", "", http.StatusOK, s.getChain)
+	s.handle("GET", "blocks/:id", http.StatusOK, s.getBlocks)
+
+	s.handle("POST", "addrs", http.StatusOK, s.genAddr)
+	s.handle("GET", "addrs/:addr/balance", http.StatusOK, s.getAddrs("balance"))
+	s.handle("GET", "addrs/:addr", http.StatusOK, s.getAddrs(""))
+	s.handle("GET", "addrs/:addr/full", http.StatusOK, s.getAddrs("full"))
+	s.handle("POST", "faucet", http.StatusOK, s.faucet)
+
+	s.handle("GET", "txs", http.StatusOK, s.getUnconfirmed)
+	s.handle("GET", "txs/:hash", http.StatusOK, s.getTXs)
+	s.handle("GET", "txs/:hash/confidence", http.StatusOK, s.getConfidence)
+	s.handle("POST", "txs/new", http.StatusCreated, s.newTX)
+	s.handle("POST", "txs/send", http.StatusCreated, s.sendTX)
+	s.handle("POST", "txs/push", http.StatusCreated, s.pushTX)
+	s.handle("POST", "txs/decode", http.StatusOK, s.decodeTX)
+
+	s.handle("POST", "wallets", http.StatusCreated, s.createWallet)
+	s.handle("GET", "wallets", http.StatusOK, s.listWallets)
+	s.handle("GET", "wallets/:name", http.StatusOK, s.getWallet)
+	s.handle("DELETE", "wallets/:name", http.StatusNoContent, s.deleteWallet)
+	s.handle("GET", "wallets/:name/addresses", http.StatusOK, s.getWallet)
+	s.handle("POST", "wallets/:name/addresses", http.StatusCreated, s.addWalletAddrs)
+	s.handle("DELETE", "wallets/:name/addresses", http.StatusNoContent, s.deleteWalletAddrs)
+	s.handle("POST", "wallets/:name/addresses/generate", http.StatusCreated, s.genWalletAddr)
+
+	s.handle("POST", "wallets/hd", http.StatusCreated, s.createHDWallet)
+	s.handle("GET", "wallets/hd/:name", http.StatusOK, s.getHDWallet)
+	s.handle("DELETE", "wallets/hd/:name", http.StatusNoContent, s.deleteHDWallet)
+	s.handle("GET", "wallets/hd/:name/addresses", http.StatusOK, s.getHDWalletAddrs)
+	s.handle("POST", "wallets/hd/:name/addresses/derive", http.StatusCreated, s.deriveHDWalletAddr)
+
+	s.handle("POST", "hooks", http.StatusCreated, s.createHook)
+	s.handle("GET", "hooks", http.StatusOK, s.listHooks)
+	s.handle("GET", "hooks/:id", http.StatusOK, s.getHook)
+	s.handle("DELETE", "hooks/:id", http.StatusNoContent, s.deleteHook)
+
+	s.handle("POST", "payments", http.StatusCreated, s.createPayFwd)
+	s.handle("GET", "payments", http.StatusOK, s.listPayFwds)
+	s.handle("GET", "payments/:id", http.StatusOK, s.getPayFwd)
+	s.handle("DELETE", "payments/:id", http.StatusNoContent, s.deletePayFwd)
+
+	for _, kind := range []string{"addrs", "txs", "blocks"} {
+		s.handle("GET", kind+"/:hash/meta", http.StatusOK, s.getMeta)
+		s.handle("PUT", kind+"/:hash/meta", http.StatusNoContent, s.putMeta)
+		s.handle("DELETE", kind+"/:hash/meta", http.StatusNoContent, s.deleteMeta)
+	}
+
+	s.handle("POST", "oap/addrs", http.StatusOK, s.genAssetAddr)
+	s.handle("POST", "oap/issue", http.StatusOK, s.issueAsset)
+	s.handle("POST", "oap/:assetid/transfer", http.StatusOK, s.transferAsset)
+	s.handle("GET", "oap/:assetid/txs", http.StatusOK, s.listAssetTXs)
+	s.handle("GET", "oap/:assetid/txs/:hash", http.StatusOK, s.getAssetTX)
+	s.handle("GET", "oap/:assetid/addrs/:addr", http.StatusOK, s.getAssetAddr)
+}
+
+//newID returns a fresh identifier for hooks, payment
+//forwards and the like.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return prefix + "-" + strconv.Itoa(s.nextID)
+}
+
+//contains reports whether list holds v.
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestStream(t *testing.T) {
+	c := context.Background()
+	srv, bcy, keys := setup(t)
+	want := map[string]bool{}
+	for _, n := range []int{3, 2, 2, 1} {
+		for i := 0; i < n; i++ {
+			want[srv.Fund(keys.Address, 1000)] = true
+		}
+		if n > 1 {
+			srv.Mine(1)
+		}
+	}
+	got := map[string]int{}
+	err := bcy.StreamAddrFull(c, keys.Address, &gobcy.AddrOptions{Limit: 3}, func(tx gobcy.TX) error {
+		got[tx.Hash]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal("StreamAddrFull error encountered: ", err)
+	}
+	for k := range want {
+		if got[k] != 1 {
+			t.Errorf("Expected TX %v once, got it %v times", k, got[k])
+		}
+	}
+	refs := 0
+	err = bcy.StreamAddrTXRefs(c, keys.Address, &gobcy.AddrOptions{Limit: 3}, func(ref gobcy.TXRef) error {
+		refs++
+		return nil
+	})
+	if err != nil || refs != len(got) {
+		t.Errorf("Expected %v TXRefs, got %v, %v", len(got), refs, err)
+	}
+	block, err := bcy.GetBlock(c, srv.Height()-2, "", nil)
+	if err != nil {
+		t.Fatal("GetBlock error encountered: ", err)
+	}
+	var txids []string
+	err = bcy.StreamBlockTXids(c, 0, block.Hash, &gobcy.BlockOptions{Limit: 1}, func(txid string) error {
+		txids = append(txids, txid)
+		return nil
+	})
+	if err != nil || strings.Join(txids, ",") != strings.Join(block.TXids, ",") {
+		t.Errorf("Expected txids %v, got %v, %v", block.TXids, txids, err)
+	}
+	//StreamBlock also returns the rest of the block
+	n := 0
+	bl, err := bcy.StreamBlock(c, 0, block.Hash, &gobcy.BlockOptions{Limit: 1}, func(string) error {
+		n++
+		return nil
+	})
+	if err != nil || bl.Hash != block.Hash || bl.Height != block.Height || bl.NumTX != block.NumTX || bl.TXids != nil || bl.NextTXs != "" || n != len(block.TXids) {
+		t.Errorf("Unexpected StreamBlock result %+v after %v txids, %v", bl, n, err)
+	}
+	stop := errors.New("stop")
+	if err = bcy.StreamBlockTXids(c, 0, block.Hash, nil, func(string) error { return stop }); err != stop {
+		t.Error("Expected the callback's error to stop the stream, got ", err)
+	}
+}
+
+//partialServer returns a server answering with first, then,
+//once release is closed (or after a second), with rest. written
+//reports whether rest was sent.
+func partialServer(first, rest string) (srv *httptest.Server, release chan struct{}, written func() bool) {
+	release = make(chan struct{})
+	done := make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(first))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+		w.Write([]byte(rest))
+		close(done)
+	}))
+	written = func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+	return
+}
+
+func TestStreamIncremental(t *testing.T) {
+	c := context.Background()
+	//the first element is handed over before the rest of the page arrives
+	srv, release, written := partialServer(`{"address": "addr", "txs": [{"hash": "a", "block_height": 5}, `,
+		`{"hash": "b", "block_height": 5}], "hasMore": false}`)
+	defer srv.Close()
+	bcy := gobcy.NewAPI("test-token", "bcy", "test", srv.Client())
+	bcy.BaseURL = srv.URL + "/v1/"
+	var got []string
+	err := bcy.StreamAddrFull(c, "addr", nil, func(tx gobcy.TX) error {
+		if tx.Hash == "a" {
+			if written() {
+				t.Error("Expected TX a before the rest of the page was sent")
+			}
+			close(release)
+		}
+		got = append(got, tx.Hash)
+		return nil
+	})
+	if err != nil || strings.Join(got, ",") != "a,b" {
+		t.Errorf("Expected TXs a,b, got %v, %v", got, err)
+	}
+	srv, release, written = partialServer(`{"hash": "h", "height": 7, "n_tx": 2, "txids": ["t1", `, `"t2"]}`)
+	defer srv.Close()
+	bcy.HTTPClient, bcy.BaseURL = srv.Client(), srv.URL+"/v1/"
+	got = nil
+	bl, err := bcy.StreamBlock(c, 7, "", nil, func(txid string) error {
+		if txid == "t1" {
+			if written() {
+				t.Error("Expected txid t1 before the rest of the page was sent")
+			}
+			close(release)
+		}
+		got = append(got, txid)
+		return nil
+	})
+	if err != nil || strings.Join(got, ",") != "t1,t2" || bl.Hash != "h" || bl.NumTX != 2 {
+		t.Errorf("Expected block h with txids t1,t2, got %+v, %v, %v", bl, got, err)
+	}
+}
@@ -0,0 +1,51 @@
+package gobcytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+func TestTokenPoolUsage(t *testing.T) {
+	c := context.Background()
+	srv, bcy, _ := setup(t)
+	srv.Tokens = []string{"test-token", "token-a"}
+	bcy.Token = ""
+	bcy.Tokens = gobcy.NewTokenPool("token-a")
+	for i := 0; i < 3; i++ {
+		if _, err := bcy.GetChain(c); err != nil {
+			t.Fatal("GetChain error encountered: ", err)
+		}
+	}
+	usage, err := bcy.CheckUsage(c)
+	if err != nil || usage.Hits.PerHour != 3 {
+		t.Errorf("Expected the usage of token-a, with 3 hits, got %+v, %v", usage.Hits, err)
+	}
+	limiter := gobcy.NewRateLimiter(gobcy.Usage{})
+	limiter.FailFast = true
+	if err = limiter.Refresh(c, &bcy); err != nil {
+		t.Fatal("Refresh error encountered: ", err)
+	}
+	//a rate limited pool fails over once per token, then
+	//gives up without a retry policy
+	var hits atomic.Int32
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "Limits reached.", "retry_after": 0.000001}`))
+	})
+	bcy.Tokens = gobcy.NewTokenPool("token-a", "token-b")
+	short, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+	if _, err = bcy.GetChain(short); !errors.Is(err, gobcy.ErrRateLimited) {
+		t.Error("Expected ErrRateLimited, got ", err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts over 2 rate limited tokens, got %v", n)
+	}
+}
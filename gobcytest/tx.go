@@ -0,0 +1,498 @@
+package gobcytest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//getUnconfirmed answers GET /txs with the mempool, newest first.
+func (s *Server) getUnconfirmed(c *call) (interface{}, *apiError) {
+	txs := []gobcy.TX{}
+	for i := len(s.order) - 1; i >= 0; i-- {
+		if rec := s.txs[s.order[i]]; rec.height < 0 {
+			txs = append(txs, s.renderTX(rec))
+		}
+	}
+	return txs, nil
+}
+
+//getTXs answers GET /txs/{hash}, including semicolon-separated
+//batches, paging inputs and outputs like BlockCypher does.
+func (s *Server) getTXs(c *call) (interface{}, *apiError) {
+	limit := c.intParam("limit", 20)
+	if limit < 1 || limit > 100 {
+		return nil, errorf(http.StatusBadRequest, "limit must be between 1 and 100")
+	}
+	instart, outstart := c.intParam("instart", 0), c.intParam("outstart", 0)
+	one := func(hash string) (interface{}, *apiError) {
+		rec := s.txs[hash]
+		if rec == nil {
+			return nil, errorf(http.StatusNotFound, "Transaction "+hash+" not found.")
+		}
+		tx := s.renderTX(rec)
+		base := s.URL + "/v1/" + s.Coin + "/" + s.Chain + "/txs/" + hash + "?limit=" + strconv.Itoa(limit)
+		if instart > len(tx.Inputs) {
+			instart = len(tx.Inputs)
+		}
+		if tx.Inputs = tx.Inputs[instart:]; len(tx.Inputs) > limit {
+			tx.Inputs = tx.Inputs[:limit]
+			tx.NextInputs = base + "&instart=" + strconv.Itoa(instart+limit)
+		}
+		if outstart > len(tx.Outputs) {
+			outstart = len(tx.Outputs)
+		}
+		if tx.Outputs = tx.Outputs[outstart:]; len(tx.Outputs) > limit {
+			tx.Outputs = tx.Outputs[:limit]
+			tx.NextOutputs = base + "&outstart=" + strconv.Itoa(outstart+limit)
+		}
+		if c.boolParam("includeHex") {
+			tx.Hex = rec.hex
+		}
+		if c.boolParam("includeConfidence") && rec.height < 0 {
+			tx.Confidence = 0.99
+		}
+		return tx, nil
+	}
+	return batch(c.parts[1], one)
+}
+
+//getConfidence answers GET /txs/{hash}/confidence.
+func (s *Server) getConfidence(c *call) (interface{}, *apiError) {
+	rec := s.txs[c.parts[1]]
+	if rec == nil {
+		return nil, errorf(http.StatusNotFound, "Transaction "+c.parts[1]+" not found.")
+	}
+	conf := gobcy.TXConf{TXHash: c.parts[1], Confidence: 1, Age: int(s.now.Sub(rec.received).Milliseconds())}
+	if rec.height < 0 {
+		conf.Confidence = 0.99
+		conf.ReceiveCount = 100
+	}
+	return conf, nil
+}
+
+//newTX answers POST /txs/new, selecting unspent outputs of
+//the input addresses and returning the data to sign.
+func (s *Server) newTX(c *call) (interface{}, *apiError) {
+	var req gobcy.TX
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	if len(req.Inputs) == 0 || len(req.Outputs) == 0 {
+		return nil, errorf(http.StatusBadRequest, "Transaction needs at least one input and one output.")
+	}
+	var from []string
+	for _, in := range req.Inputs {
+		if strings.HasPrefix(in.ScriptType, "multisig") {
+			return nil, errorf(http.StatusBadRequest, "gobcytest: multisig inputs are not supported.")
+		}
+		if in.WalletName != "" {
+			w := s.wallets[c.token][in.WalletName]
+			if w == nil {
+				return nil, errorf(http.StatusNotFound, "Wallet "+in.WalletName+" not found.")
+			}
+			from = append(from, w.addresses...)
+			continue
+		}
+		for _, a := range in.Addresses {
+			if !s.validAddr(a) {
+				return nil, errorf(http.StatusBadRequest, "Invalid input address: "+a+".")
+			}
+			from = append(from, a)
+		}
+	}
+	fee := s.Fee
+	if req.Fees.Sign() > 0 {
+		fee = req.Fees.Int64()
+	}
+	var tx gobcy.TX
+	var want int64
+	sweep := len(req.Outputs) == 1 && req.Outputs[0].Value.Int64() == -1
+	for _, out := range req.Outputs {
+		o := gobcy.TXOutput{Value: out.Value, Addresses: out.Addresses, ScriptType: out.ScriptType}
+		if out.ScriptType == "null-data" {
+			o.Addresses = nil
+			o.DataHex = out.DataHex
+			o.Script = "6a" + out.DataHex
+			tx.Outputs = append(tx.Outputs, o)
+			continue
+		}
+		if len(out.Addresses) != 1 || !s.validAddr(out.Addresses[0]) {
+			return nil, errorf(http.StatusBadRequest, "Invalid output addresses: "+strings.Join(out.Addresses, ", ")+".")
+		}
+		o.ScriptType = s.scriptType(out.Addresses[0])
+		o.Script = s.outputScript(out.Addresses[0])
+		want += out.Value.Int64()
+		tx.Outputs = append(tx.Outputs, o)
+	}
+	var have int64
+	for _, addr := range from {
+		for _, op := range s.unspent(addr) {
+			if !sweep && have >= want+fee {
+				break
+			}
+			o := s.utxos[op]
+			have += o.value
+			tx.Inputs = append(tx.Inputs, gobcy.TXInput{
+				PrevHash:    op.hash,
+				OutputIndex: op.n,
+				OutputValue: int(o.value),
+				Addresses:   []string{addr},
+				Sequence:    4294967295,
+				ScriptType:  "pay-to-pubkey-hash",
+			})
+		}
+	}
+	if sweep {
+		want = have - fee
+		tx.Outputs[0].Value = *big.NewInt(want)
+	}
+	if have < want+fee || want <= 0 {
+		e := errorf(http.StatusBadRequest, "")
+		e.errs = []string{"Not enough funds in " + strconv.Itoa(len(tx.Inputs)) + " inputs to pay for " +
+			strconv.Itoa(len(tx.Outputs)) + " outputs, missing " + strconv.FormatInt(have-want-fee, 10) + "."}
+		return nil, e
+	}
+	if change := have - want - fee; change > 0 {
+		to := req.ChangeAddress
+		if to == "" {
+			to = from[0]
+		}
+		tx.Outputs = append(tx.Outputs, gobcy.TXOutput{
+			Value:      *big.NewInt(change),
+			Addresses:  []string{to},
+			ScriptType: s.scriptType(to),
+			Script:     s.outputScript(to),
+		})
+	}
+	skel := gobcy.TXSkel{Trans: tx, Signatures: []string{}}
+	skel.Trans.Hash = txHash(tx)
+	skel.Trans.BlockHeight = -1
+	skel.Trans.Fees = *big.NewInt(fee)
+	skel.Trans.Total = *big.NewInt(have - fee)
+	skel.Trans.Received = s.now
+	skel.Trans.Addresses = txAddrs(tx)
+	skel.Trans.VinSize, skel.Trans.VoutSize = len(tx.Inputs), len(tx.Outputs)
+	for i := range tx.Inputs {
+		pre := preimage(tx, i)
+		skel.ToSign = append(skel.ToSign, hashHex(pre))
+		if c.boolParam("includeToSignTx") {
+			skel.ToSignTX = append(skel.ToSignTX, hex.EncodeToString(pre))
+		}
+	}
+	return skel, nil
+}
+
+//preimage returns the data hashed into the ToSign entry of input i.
+func preimage(tx gobcy.TX, i int) []byte {
+	type out struct {
+		Value   string
+		Address string
+		Data    string
+	}
+	v := struct {
+		Prev  []string
+		Outs  []out
+		Input int
+	}{Input: i}
+	for _, in := range tx.Inputs {
+		v.Prev = append(v.Prev, in.PrevHash+":"+strconv.Itoa(in.OutputIndex))
+	}
+	for _, o := range tx.Outputs {
+		v.Outs = append(v.Outs, out{o.Value.String(), firstAddr(o.Addresses), o.DataHex})
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
+
+//sendTX answers POST /txs/send, checking the signatures of a
+//skeleton from newTX before adding it to the mempool.
+func (s *Server) sendTX(c *call) (interface{}, *apiError) {
+	var skel gobcy.TXSkel
+	if err := c.decode(&skel); err != nil {
+		return nil, err
+	}
+	tx := gobcy.TX{Inputs: skel.Trans.Inputs, Outputs: skel.Trans.Outputs}
+	n := len(tx.Inputs)
+	if n == 0 || len(skel.ToSign) != n || len(skel.Signatures) != n || len(skel.PubKeys) != n {
+		return nil, errorf(http.StatusBadRequest, "Need one tosign, signature and pubkey per input.")
+	}
+	var in, out int64
+	for i, v := range tx.Inputs {
+		if skel.ToSign[i] != hashHex(preimage(tx, i)) {
+			return nil, errorf(http.StatusBadRequest, "Transaction data doesn't match tosign for input "+strconv.Itoa(i)+".")
+		}
+		o := s.utxos[outpoint{v.PrevHash, v.OutputIndex}]
+		if o == nil {
+			return nil, errorf(http.StatusBadRequest, "Input "+strconv.Itoa(i)+" spends unknown output.")
+		}
+		if o.spentBy != "" {
+			return nil, errorf(http.StatusBadRequest, "Input "+strconv.Itoa(i)+" already spent by "+o.spentBy+".")
+		}
+		if err := s.checkSig(o.address, skel.PubKeys[i], skel.Signatures[i], skel.ToSign[i]); err != nil {
+			return nil, errorf(http.StatusBadRequest, "Error validating signature of input "+strconv.Itoa(i)+": "+err.Error())
+		}
+		in += o.value
+	}
+	for _, o := range tx.Outputs {
+		out += o.Value.Int64()
+	}
+	if out > in {
+		return nil, errorf(http.StatusBadRequest, "Outputs exceed inputs.")
+	}
+	hash := s.addTX(tx, "")
+	skel.Trans = s.renderTX(s.txs[hash])
+	return skel, nil
+}
+
+//checkSig verifies that sig signs tosign with the
+//public key behind addr.
+func (s *Server) checkSig(addr, pubHex, sigHex, tosign string) error {
+	pubData, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return err
+	}
+	pub, err := btcec.ParsePubKey(pubData)
+	if err != nil {
+		return err
+	}
+	if s.pubKeyAddr(pub.SerializeCompressed()) != addr {
+		return errors.New("public key doesn't match address " + addr)
+	}
+	sigData, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return err
+	}
+	sig, err := ecdsa.ParseDERSignature(sigData)
+	if err != nil {
+		return err
+	}
+	msg, err := hex.DecodeString(tosign)
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(msg, pub) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+//pushTX answers POST /txs/push with a raw transaction. Scripts
+//aren't verified, but every input must spend a known, unspent
+//output of the Server.
+func (s *Server) pushTX(c *call) (interface{}, *apiError) {
+	tx, rawHex, apiErr := s.readRawTX(c)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if _, ok := s.txs[tx.Hash]; ok {
+		return nil, errorf(http.StatusBadRequest, "Transaction "+tx.Hash+" already exists.")
+	}
+	for i, v := range tx.Inputs {
+		o := s.utxos[outpoint{v.PrevHash, v.OutputIndex}]
+		if o == nil {
+			return nil, errorf(http.StatusBadRequest, "Input "+strconv.Itoa(i)+" spends unknown output.")
+		}
+		if o.spentBy != "" {
+			return nil, errorf(http.StatusBadRequest, "Input "+strconv.Itoa(i)+" already spent by "+o.spentBy+".")
+		}
+	}
+	hash := s.addRawTX(tx, rawHex)
+	return gobcy.TXSkel{Trans: s.renderTX(s.txs[hash])}, nil
+}
+
+//decodeTX answers POST /txs/decode.
+func (s *Server) decodeTX(c *call) (interface{}, *apiError) {
+	tx, _, apiErr := s.readRawTX(c)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return tx, nil
+}
+
+//readRawTX reads and parses the {"tx": hex} body of push
+//and decode calls.
+func (s *Server) readRawTX(c *call) (tx gobcy.TX, rawHex string, apiErr *apiError) {
+	var req struct {
+		TX string `json:"tx"`
+	}
+	if apiErr = c.decode(&req); apiErr != nil {
+		return
+	}
+	raw, err := hex.DecodeString(req.TX)
+	if err != nil {
+		apiErr = errorf(http.StatusBadRequest, "Invalid hex: "+err.Error())
+		return
+	}
+	tx, err = s.parseRawTX(raw)
+	if err != nil {
+		apiErr = errorf(http.StatusBadRequest, "Couldn't decode transaction: "+err.Error())
+	}
+	return tx, req.TX, apiErr
+}
+
+//addRawTX records a pushed transaction under its real hash.
+func (s *Server) addRawTX(tx gobcy.TX, rawHex string) string {
+	var in, out int64
+	for i, v := range tx.Inputs {
+		o := s.utxos[outpoint{v.PrevHash, v.OutputIndex}]
+		o.spentBy = tx.Hash
+		in += o.value
+		tx.Inputs[i].OutputValue = int(o.value)
+	}
+	for i, v := range tx.Outputs {
+		s.utxos[outpoint{tx.Hash, i}] = &output{
+			value:      v.Value.Int64(),
+			address:    firstAddr(v.Addresses),
+			script:     v.Script,
+			scriptType: v.ScriptType,
+		}
+		out += v.Value.Int64()
+	}
+	tx.Total = *big.NewInt(out)
+	if in > out {
+		tx.Fees = *big.NewInt(in - out)
+	}
+	s.txs[tx.Hash] = &txRecord{tx: tx, hex: rawHex, height: -1, received: s.now}
+	s.order = append(s.order, tx.Hash)
+	s.forwardPayments(tx)
+	return tx.Hash
+}
+
+//rawReader reads the fields of a serialized transaction.
+type rawReader struct {
+	data []byte
+	err  error
+}
+
+func (r *rawReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		if r.err == nil {
+			r.err = errors.New("unexpected end of data")
+		}
+		return make([]byte, n&0xffff)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *rawReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *rawReader) varInt() int {
+	b := r.next(1)[0]
+	switch b {
+	case 0xfd:
+		return int(binary.LittleEndian.Uint16(r.next(2)))
+	case 0xfe:
+		return int(binary.LittleEndian.Uint32(r.next(4)))
+	case 0xff:
+		return int(binary.LittleEndian.Uint64(r.next(8)))
+	}
+	return int(b)
+}
+
+//parseRawTX decodes a serialized Bitcoin-style transaction,
+//with or without segwit data, filling in the addresses and
+//values of inputs that spend outputs known to the Server.
+func (s *Server) parseRawTX(raw []byte) (tx gobcy.TX, err error) {
+	if len(raw) < 10 {
+		return tx, errors.New("transaction too short")
+	}
+	r := &rawReader{data: raw}
+	tx.Ver = int(r.uint32())
+	segwit := len(r.data) > 1 && r.data[0] == 0 && r.data[1] == 1
+	if segwit {
+		r.next(2)
+	}
+	stripped := append([]byte(nil), raw[:4]...)
+	start := len(raw) - len(r.data)
+	nIn := r.varInt()
+	for i := 0; i < nIn && r.err == nil; i++ {
+		prev := reverse(r.next(32))
+		in := gobcy.TXInput{PrevHash: hex.EncodeToString(prev), OutputIndex: int(r.uint32())}
+		in.Script = hex.EncodeToString(r.next(r.varInt()))
+		in.Sequence = int(r.uint32())
+		in.Addresses = []string{}
+		if o := s.utxos[outpoint{in.PrevHash, in.OutputIndex}]; o != nil {
+			in.OutputValue = int(o.value)
+			in.Addresses = []string{o.address}
+			in.ScriptType = o.scriptType
+		}
+		tx.Inputs = append(tx.Inputs, in)
+	}
+	nOut := r.varInt()
+	for i := 0; i < nOut && r.err == nil; i++ {
+		value := binary.LittleEndian.Uint64(r.next(8))
+		script := r.next(r.varInt())
+		out := gobcy.TXOutput{Value: *new(big.Int).SetUint64(value), Script: hex.EncodeToString(script)}
+		out.Addresses, out.ScriptType = s.scriptAddrs(script)
+		if out.ScriptType == "null-data" && len(script) > 2 {
+			out.DataHex = hex.EncodeToString(script[2:])
+		}
+		tx.Outputs = append(tx.Outputs, out)
+	}
+	end := len(raw) - len(r.data)
+	if segwit {
+		for i := 0; i < nIn && r.err == nil; i++ {
+			for n := r.varInt(); n > 0 && r.err == nil; n-- {
+				r.next(r.varInt())
+			}
+		}
+	}
+	tx.LockTime = int(r.uint32())
+	if r.err != nil {
+		return tx, r.err
+	}
+	if len(r.data) != 0 {
+		return tx, errors.New("trailing data after transaction")
+	}
+	stripped = append(stripped, raw[start:end]...)
+	stripped = append(stripped, raw[len(raw)-4:]...)
+	first := sha256.Sum256(stripped)
+	second := sha256.Sum256(first[:])
+	tx.Hash = hex.EncodeToString(reverse(second[:]))
+	tx.Size = len(raw)
+	tx.VinSize, tx.VoutSize = len(tx.Inputs), len(tx.Outputs)
+	tx.Addresses = txAddrs(tx)
+	tx.BlockHeight = -1
+	tx.Hex = hex.EncodeToString(raw)
+	return
+}
+
+//scriptAddrs returns the address and script type of an output script.
+func (s *Server) scriptAddrs(script []byte) ([]string, string) {
+	switch {
+	case len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 20 && script[23] == 0x88 && script[24] == 0xac:
+		return []string{base58.CheckEncode(script[3:23], s.versions().pubKeyHash)}, "pay-to-pubkey-hash"
+	case len(script) == 23 && script[0] == 0xa9 && script[1] == 20 && script[22] == 0x87:
+		return []string{base58.CheckEncode(script[2:22], s.versions().scriptHash)}, "pay-to-script-hash"
+	case (len(script) == 35 || len(script) == 67) && int(script[0]) == len(script)-2 && script[len(script)-1] == 0xac:
+		return []string{s.pubKeyAddr(script[1 : len(script)-1])}, "pay-to-pubkey"
+	case len(script) > 0 && script[0] == 0x6a:
+		return nil, "null-data"
+	}
+	return nil, "unknown"
+}
+
+//reverse returns a reversed copy of b.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, v := range b {
+		out[len(b)-1-i] = v
+	}
+	return out
+}
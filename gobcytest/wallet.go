@@ -0,0 +1,315 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ThePiachu/gobcy/v2"
+)
+
+//wallet is a named set of addresses.
+type wallet struct {
+	addresses []string
+}
+
+//hdWallet is a named HD wallet. The Server doesn't do real
+//BIP32 derivation: child addresses are derived by hashing the
+//extended public key with the subchain and index.
+type hdWallet struct {
+	name      string
+	xpub      string
+	subchains []int
+	derived   map[int][]hdAddr
+}
+
+//hdAddr is an address derived from an HD wallet.
+type hdAddr struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+	Public  string `json:"public"`
+}
+
+//addresses lists every derived address of w.
+func (w *hdWallet) addresses() (all []string) {
+	for _, i := range w.subchains {
+		for _, a := range w.derived[i] {
+			all = append(all, a.Address)
+		}
+	}
+	return
+}
+
+//derive adds the next address of subchain i.
+func (s *Server) derive(w *hdWallet, i int) hdAddr {
+	n := len(w.derived[i])
+	pub := append([]byte{0x02}, hash20([]byte(w.xpub+"/"+strconv.Itoa(i)+"/"+strconv.Itoa(n)))...)
+	pub = append(pub, hash20([]byte(w.xpub))[:12]...)
+	a := hdAddr{
+		Address: s.pubKeyAddr(pub),
+		Path:    "m/" + strconv.Itoa(i) + "/" + strconv.Itoa(n),
+		Public:  hex.EncodeToString(pub),
+	}
+	w.derived[i] = append(w.derived[i], a)
+	return a
+}
+
+//renderHDWallet returns the public view of an HD wallet,
+//with the given addresses of each subchain.
+func (s *Server) renderHDWallet(w *hdWallet) gobcy.HDWallet {
+	return s.renderHDWalletAddrs(w, func(hdAddr) bool { return true })
+}
+
+//renderHDWalletAddrs is renderHDWallet with only the
+//addresses passing keep.
+func (s *Server) renderHDWalletAddrs(w *hdWallet, keep func(hdAddr) bool) (hd gobcy.HDWallet) {
+	type chain struct {
+		ChainAddr []hdAddr `json:"chain_addresses"`
+		Index     int      `json:"index"`
+	}
+	view := struct {
+		Name      string  `json:"name"`
+		ExtPubKey string  `json:"extended_public_key"`
+		Subchains []int   `json:"subchain_indexes,omitempty"`
+		Chains    []chain `json:"chains"`
+	}{Name: w.name, ExtPubKey: w.xpub, Subchains: w.subchains}
+	for _, i := range w.subchains {
+		ch := chain{Index: i}
+		for _, a := range w.derived[i] {
+			if keep(a) {
+				ch.ChainAddr = append(ch.ChainAddr, a)
+			}
+		}
+		view.Chains = append(view.Chains, ch)
+	}
+	data, _ := json.Marshal(view)
+	json.Unmarshal(data, &hd)
+	return
+}
+
+//createWallet answers POST /wallets.
+func (s *Server) createWallet(c *call) (interface{}, *apiError) {
+	var req gobcy.Wallet
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "Wallet name is required.")
+	}
+	if s.wallets[c.token][req.Name] != nil || s.hd[c.token][req.Name] != nil {
+		return nil, errorf(http.StatusConflict, "Wallet "+req.Name+" already exists.")
+	}
+	for _, a := range req.Addresses {
+		if !s.validAddr(a) {
+			return nil, errorf(http.StatusBadRequest, "Invalid address: "+a+".")
+		}
+	}
+	if s.wallets[c.token] == nil {
+		s.wallets[c.token] = make(map[string]*wallet)
+	}
+	s.wallets[c.token][req.Name] = &wallet{addresses: append([]string{}, req.Addresses...)}
+	return gobcy.Wallet{Name: req.Name, Addresses: req.Addresses}, nil
+}
+
+//listWallets answers GET /wallets.
+func (s *Server) listWallets(c *call) (interface{}, *apiError) {
+	names := []string{}
+	for name := range s.wallets[c.token] {
+		names = append(names, name)
+	}
+	for name := range s.hd[c.token] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return map[string][]string{"wallet_names": names}, nil
+}
+
+//lookupWallet returns the named wallet of the call's token.
+func (s *Server) lookupWallet(c *call) (*wallet, *apiError) {
+	w := s.wallets[c.token][c.parts[1]]
+	if w == nil {
+		return nil, errorf(http.StatusNotFound, "Wallet "+c.parts[1]+" not found.")
+	}
+	return w, nil
+}
+
+//getWallet answers GET /wallets/{name} and /wallets/{name}/addresses.
+func (s *Server) getWallet(c *call) (interface{}, *apiError) {
+	w, err := s.lookupWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	return gobcy.Wallet{Name: c.parts[1], Addresses: w.addresses}, nil
+}
+
+//deleteWallet answers DELETE /wallets/{name}.
+func (s *Server) deleteWallet(c *call) (interface{}, *apiError) {
+	if _, err := s.lookupWallet(c); err != nil {
+		return nil, err
+	}
+	delete(s.wallets[c.token], c.parts[1])
+	return nil, nil
+}
+
+//addWalletAddrs answers POST /wallets/{name}/addresses.
+func (s *Server) addWalletAddrs(c *call) (interface{}, *apiError) {
+	w, err := s.lookupWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	var req gobcy.Wallet
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	for _, a := range req.Addresses {
+		if !s.validAddr(a) {
+			return nil, errorf(http.StatusBadRequest, "Invalid address: "+a+".")
+		}
+		if !contains(w.addresses, a) {
+			w.addresses = append(w.addresses, a)
+		}
+	}
+	wal := gobcy.Wallet{Name: c.parts[1], Addresses: w.addresses}
+	if c.boolParam("omitWalletAddresses") {
+		wal.Addresses = nil
+	}
+	return wal, nil
+}
+
+//deleteWalletAddrs answers DELETE /wallets/{name}/addresses.
+func (s *Server) deleteWalletAddrs(c *call) (interface{}, *apiError) {
+	w, err := s.lookupWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	remove := strings.Split(c.param("address"), ";")
+	kept := w.addresses[:0]
+	for _, a := range w.addresses {
+		if !contains(remove, a) {
+			kept = append(kept, a)
+		}
+	}
+	w.addresses = kept
+	return nil, nil
+}
+
+//genWalletAddr answers POST /wallets/{name}/addresses/generate.
+func (s *Server) genWalletAddr(c *call) (interface{}, *apiError) {
+	w, err := s.lookupWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	_, keys := s.newKey()
+	w.addresses = append(w.addresses, keys.Address)
+	return struct {
+		gobcy.Wallet
+		gobcy.AddrKeychain
+	}{gobcy.Wallet{Name: c.parts[1], Addresses: w.addresses}, keys}, nil
+}
+
+//createHDWallet answers POST /wallets/hd.
+func (s *Server) createHDWallet(c *call) (interface{}, *apiError) {
+	var req gobcy.HDWallet
+	if err := c.decode(&req); err != nil {
+		return nil, err
+	}
+	if req.Name == "" || req.ExtPubKey == "" {
+		return nil, errorf(http.StatusBadRequest, "Wallet name and extended_public_key are required.")
+	}
+	if s.wallets[c.token][req.Name] != nil || s.hd[c.token][req.Name] != nil {
+		return nil, errorf(http.StatusConflict, "Wallet "+req.Name+" already exists.")
+	}
+	w := &hdWallet{name: req.Name, xpub: req.ExtPubKey, subchains: req.SubchainIndexes, derived: make(map[int][]hdAddr)}
+	if len(w.subchains) == 0 {
+		w.subchains = []int{0}
+	}
+	for _, i := range w.subchains {
+		s.derive(w, i)
+	}
+	if s.hd[c.token] == nil {
+		s.hd[c.token] = make(map[string]*hdWallet)
+	}
+	s.hd[c.token][req.Name] = w
+	return s.renderHDWallet(w), nil
+}
+
+//lookupHDWallet returns the named HD wallet of the call's token.
+func (s *Server) lookupHDWallet(c *call) (*hdWallet, *apiError) {
+	w := s.hd[c.token][c.parts[2]]
+	if w == nil {
+		return nil, errorf(http.StatusNotFound, "Wallet "+c.parts[2]+" not found.")
+	}
+	return w, nil
+}
+
+//getHDWallet answers GET /wallets/hd/{name}.
+func (s *Server) getHDWallet(c *call) (interface{}, *apiError) {
+	w, err := s.lookupHDWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.renderHDWallet(w), nil
+}
+
+//deleteHDWallet answers DELETE /wallets/hd/{name}.
+func (s *Server) deleteHDWallet(c *call) (interface{}, *apiError) {
+	if _, err := s.lookupHDWallet(c); err != nil {
+		return nil, err
+	}
+	delete(s.hd[c.token], c.parts[2])
+	return nil, nil
+}
+
+//getHDWalletAddrs answers GET /wallets/hd/{name}/addresses,
+//honouring the used, zerobalance and subchain_index filters.
+func (s *Server) getHDWalletAddrs(c *call) (interface{}, *apiError) {
+	w, err := s.lookupHDWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	keep := func(a hdAddr) bool {
+		v := s.viewAddrs([]string{a.Address}, 0)
+		if p := c.param("used"); p != "" && (v.addr.FinalNumTX > 0) != (p == "true") {
+			return false
+		}
+		if p := c.param("zerobalance"); p != "" && (v.addr.FinalBalance.Sign() == 0) != (p == "true") {
+			return false
+		}
+		if p := c.param("subchain_index"); p != "" && !strings.HasPrefix(a.Path, "m/"+p+"/") {
+			return false
+		}
+		return true
+	}
+	return s.renderHDWalletAddrs(w, keep), nil
+}
+
+//deriveHDWalletAddr answers POST /wallets/hd/{name}/addresses/derive
+//with a partial HD wallet holding only the new addresses.
+func (s *Server) deriveHDWalletAddr(c *call) (interface{}, *apiError) {
+	w, err := s.lookupHDWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	sub := c.intParam("subchain_index", w.subchains[0])
+	if w.derived[sub] == nil && !containsInt(w.subchains, sub) {
+		return nil, errorf(http.StatusBadRequest, "Unknown subchain_index "+strconv.Itoa(sub)+".")
+	}
+	fresh := make(map[string]bool)
+	for n := c.intParam("count", 1); n > 0; n-- {
+		fresh[s.derive(w, sub).Address] = true
+	}
+	return s.renderHDWalletAddrs(w, func(a hdAddr) bool { return fresh[a.Address] }), nil
+}
+
+//containsInt reports whether list holds v.
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
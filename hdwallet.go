@@ -15,7 +15,7 @@ func (api *API) CreateHDWallet(c context.Context, req HDWallet) (wal HDWallet, e
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &req, &wal)
+	err = api.createOwned(c, u, &req, &wal, func() string { return walletID(wal.Name) })
 	return
 }
 
@@ -45,7 +45,7 @@ func (api *API) GetHDWallet(c context.Context, name string) (wal HDWallet, err e
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &wal)
+	err = api.walletResponse(c, name, "GET", u, nil, &wal)
 	return
 }
 
@@ -57,7 +57,7 @@ func (api *API) GetAddrHDWallet(c context.Context, name string, params map[strin
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &addrs)
+	err = api.walletResponse(c, name, "GET", u, nil, &addrs)
 	return
 }
 
@@ -69,7 +69,7 @@ func (api *API) DeriveAddrHDWallet(c context.Context, name string, params map[st
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, nil, &wal)
+	err = api.walletResponse(c, name, "POST", u, nil, &wal)
 	return
 }
 
@@ -80,6 +80,6 @@ func (api *API) DeleteHDWallet(c context.Context, name string) (err error) {
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.deleteOwned(c, walletID(name), u)
 	return
 }
@@ -0,0 +1,118 @@
+package gobcy
+
+import (
+	"iter"
+
+	"golang.org/x/net/context"
+)
+
+//Cursor walks through a sequence fetched page by page,
+//like the history of an address:
+//	cur := bc.NewAddrCursor(c, addr, nil)
+//	for cur.Next() {
+//		ref := cur.Value()
+//		...
+//	}
+//	if err := cur.Err(); err != nil {
+//		...
+//	}
+//Only one page is held in memory at a time.
+type Cursor[T any] struct {
+	c     context.Context
+	fetch func(c context.Context) (page []T, more bool, err error)
+	buf   []T
+	value T
+	more  bool
+	err   error
+}
+
+//Next advances to the next value, fetching a new page if
+//needed. It returns false at the end or on error.
+func (cur *Cursor[T]) Next() bool {
+	for len(cur.buf) == 0 {
+		if !cur.more || cur.err != nil {
+			return false
+		}
+		cur.buf, cur.more, cur.err = cur.fetch(cur.c)
+	}
+	cur.value, cur.buf = cur.buf[0], cur.buf[1:]
+	return true
+}
+
+//Value returns the current value.
+func (cur *Cursor[T]) Value() T {
+	return cur.value
+}
+
+//Err returns the error that stopped the cursor, if any.
+func (cur *Cursor[T]) Err() error {
+	return cur.err
+}
+
+//All returns the rest of the cursor's values as an iterator,
+//ending with a zero value and the error if one stops it.
+func (cur *Cursor[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for cur.Next() {
+			if !yield(cur.Value(), nil) {
+				return
+			}
+		}
+		if cur.err != nil {
+			var zero T
+			yield(zero, cur.err)
+		}
+	}
+}
+
+//NewAddrCursor returns a Cursor over the TXRefs of the given
+//address: unconfirmed ones first, then confirmed ones from the
+//newest. Unlike paging with GetAddrNext, every TXRef (identified
+//by its hash and input/output index) comes up exactly once, even
+//when a page ends in the middle of a block; a block holding more
+//TXRefs than the largest page (2000, or 50 TXs for the full cursor)
+//stops the cursor with an error instead. For incremental syncs,
+//set opts.After to the last height already processed. opts may
+//be nil; its Limit sets the page size.
+func (api *API) NewAddrCursor(c context.Context, hash string, opts *AddrOptions) *Cursor[TXRef] {
+	s, err := api.addrTXRefStream(hash, opts)
+	return newAddrCursor(c, s, err)
+}
+
+//NewAddrFullCursor is NewAddrCursor for the full TXs of the
+//given address, each coming up exactly once.
+func (api *API) NewAddrFullCursor(c context.Context, hash string, opts *AddrOptions) *Cursor[TX] {
+	s, err := api.addrFullStream(hash, opts)
+	return newAddrCursor(c, s, err)
+}
+
+//AddrHistory iterates over the TXRefs of the given address,
+//like NewAddrCursor:
+//	for ref, err := range bc.AddrHistory(c, addr, nil) {
+//		if err != nil {
+//			...
+//		}
+//	}
+func (api *API) AddrHistory(c context.Context, hash string, opts *AddrOptions) iter.Seq2[TXRef, error] {
+	return api.NewAddrCursor(c, hash, opts).All()
+}
+
+//AddrFullHistory iterates over the full TXs of the given
+//address, like NewAddrFullCursor.
+func (api *API) AddrFullHistory(c context.Context, hash string, opts *AddrOptions) iter.Seq2[TX, error] {
+	return api.NewAddrFullCursor(c, hash, opts).All()
+}
+
+//newAddrCursor returns a Cursor over the elements of s, or
+//one failing with err.
+func newAddrCursor[T any](c context.Context, s *addrStream[T], err error) *Cursor[T] {
+	cur := &Cursor[T]{c: c, more: true, err: err}
+	cur.fetch = func(c context.Context) (values []T, more bool, err error) {
+		more, err = s.page(c, func(v T) error {
+			values = append(values, v)
+			return nil
+		})
+		return
+	}
+	return cur
+}
@@ -12,18 +12,19 @@ func (api *API) CreateHook(c context.Context, hook Hook) (result Hook, err error
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &hook, &result)
+	err = api.createOwned(c, u, &hook, &result, func() string { return result.ID })
 	return
 }
 
 //ListHooks returns a slice of WebHooks
-//associated with your API.Token.
+//associated with your API.Token, or with
+//any token of API.Tokens.
 func (api *API) ListHooks(c context.Context) (hooks []Hook, err error) {
 	u, err := api.buildURL("/hooks", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &hooks)
+	hooks, err = listOwned(c, api, u, func(v Hook) string { return v.ID })
 	return
 }
 
@@ -33,7 +34,11 @@ func (api *API) GetHook(c context.Context, id string) (hook Hook, err error) {
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &hook)
+	req, err := newRequest("GET", u, nil)
+	if err != nil {
+		return
+	}
+	err = api.ownedResponse(c, id, req, &hook)
 	return
 }
 
@@ -44,6 +49,6 @@ func (api *API) DeleteHook(c context.Context, id string) (err error) {
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.deleteOwned(c, id, u)
 	return
 }
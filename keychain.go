@@ -0,0 +1,79 @@
+package gobcy
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"golang.org/x/crypto/sha3"
+)
+
+//GenerateKeychain generates a key pair locally, unlike
+//GenAddrKeychain, so the private key never leaves the machine.
+//typ picks the address of the key: P2PKH for a compressed
+//pay-to-pubkey-hash address like GenAddrKeychain's, P2SH for
+//P2SH-wrapped segwit (P2SH-P2WPKH), P2WPKH for native segwit,
+//and EthAddr on Ethereum networks. The segwit ones are refused
+//on networks without segwit (no Bech32HRP). The AddrKeychain can
+//be funded with Faucet, and its Private key used with TXSkel.Sign.
+func GenerateKeychain(coin, chain string, typ AddrType) (pair AddrKeychain, err error) {
+	n, ok := LookupNetwork(coin, chain)
+	if !ok {
+		err = errors.New("GenerateKeychain: unknown network " + coin + "/" + chain)
+		return
+	}
+	if n.Account != (typ == EthAddr) {
+		err = errors.New("GenerateKeychain: can't make " + typ.String() + " addresses on " + n.String())
+		return
+	}
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		return
+	}
+	pair.Private = hex.EncodeToString(priv.Serialize())
+	if n.Account {
+		//Ethereum addresses hash the uncompressed key without its 0x04 prefix
+		pub := priv.PubKey().SerializeUncompressed()[1:]
+		h := sha3.NewLegacyKeccak256()
+		h.Write(pub)
+		pair.Public = hex.EncodeToString(pub)
+		pair.Address = hex.EncodeToString(h.Sum(nil)[12:])
+		return
+	}
+	pub := priv.PubKey().SerializeCompressed()
+	pair.Public = hex.EncodeToString(pub)
+	pair.Wif = base58.CheckEncode(append(priv.Serialize(), 0x01), n.WIFVersion)
+	hash := btcutil.Hash160(pub)
+	if (typ == P2SH || typ == P2WPKH) && n.Bech32HRP == "" {
+		//without segwit, the P2SH-P2WPKH redeem script is anyone-can-spend
+		err = errors.New("GenerateKeychain: " + n.String() + " has no segwit addresses")
+		return AddrKeychain{}, err
+	}
+	switch typ {
+	case P2PKH:
+		pair.Address = base58.CheckEncode(hash, n.PubKeyHashVersion)
+	case P2SH:
+		redeem := append([]byte{0x00, 0x14}, hash...)
+		pair.Address = base58.CheckEncode(btcutil.Hash160(redeem), n.ScriptHashVersion)
+	case P2WPKH:
+		data, err := bech32.ConvertBits(hash, 8, 5, true)
+		if err != nil {
+			return AddrKeychain{}, err
+		}
+		if pair.Address, err = bech32.Encode(n.Bech32HRP, append([]byte{0}, data...)); err != nil {
+			return AddrKeychain{}, err
+		}
+	default:
+		err = errors.New("GenerateKeychain: can't make " + typ.String() + " addresses")
+		return AddrKeychain{}, err
+	}
+	return
+}
+
+//GenerateKeychain is GenerateKeychain for the API's coin/chain.
+func (api *API) GenerateKeychain(typ AddrType) (pair AddrKeychain, err error) {
+	return GenerateKeychain(api.Coin, api.Chain, typ)
+}
@@ -0,0 +1,41 @@
+package gobcy
+
+import "testing"
+
+func TestGenerateKeychain(t *testing.T) {
+	for _, v := range []struct {
+		coin, chain string
+		typ         AddrType
+	}{
+		{"btc", "main", P2PKH},
+		{"btc", "main", P2SH},
+		{"btc", "test3", P2WPKH},
+		{"ltc", "main", P2WPKH},
+		{"doge", "main", P2PKH},
+		{"eth", "main", EthAddr},
+	} {
+		keys, err := GenerateKeychain(v.coin, v.chain, v.typ)
+		if err != nil {
+			t.Errorf("GenerateKeychain %v/%v %v error encountered: %v", v.coin, v.chain, v.typ, err)
+			continue
+		}
+		if addr, err := ParseAddress(v.coin, v.chain, keys.Address); err != nil || addr.Type != v.typ {
+			t.Errorf("GenerateKeychain %v/%v returned %v, parsed as %v, %v", v.coin, v.chain, keys.Address, addr.Type, err)
+		}
+	}
+	for _, v := range []struct {
+		coin, chain string
+		typ         AddrType
+	}{
+		{"bcy", "test", P2WPKH},
+		{"doge", "main", P2SH},
+		{"dash", "main", P2SH},
+		{"eth", "main", P2PKH},
+		{"btc", "main", EthAddr},
+		{"btc", "main", P2TR},
+	} {
+		if _, err := GenerateKeychain(v.coin, v.chain, v.typ); err == nil {
+			t.Errorf("Expected error generating %v on %v/%v, did not receive one", v.typ, v.coin, v.chain)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package gobcy
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//RateLimiter keeps calls within a token's limits on the client
+//side, so they wait (or fail fast) before BlockCypher rejects
+//them with a 429. Every call draws from the per-second, per-hour
+//and per-day API budgets; creating hooks also draws from the
+//hooks budget and confidence lookups from the confidence budget.
+//A RateLimiter can be shared by several APIs using the same token.
+type RateLimiter struct {
+	//FailFast makes calls return a *LimitError instead of
+	//waiting for the budget to refill.
+	FailFast bool
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+//LimitError is returned by calls rejected by a FailFast
+//RateLimiter. It matches ErrRateLimited with errors.Is.
+type LimitError struct {
+	//Budget is the exhausted budget, e.g. "api/hour".
+	Budget string
+	//Wait is how long until the budget allows another call.
+	Wait time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return "RateLimiter: " + e.Budget + " budget exhausted, retry in " + e.Wait.String()
+}
+
+//Is makes a LimitError match ErrRateLimited.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+//bucket is a token bucket holding up to limit tokens,
+//refilled evenly over window.
+type bucket struct {
+	limit  int
+	window time.Duration
+	tokens float64
+	last   time.Time
+}
+
+//refill adds the tokens accumulated since the last refill.
+func (b *bucket) refill(now time.Time) {
+	rate := float64(b.limit) / float64(b.window)
+	b.tokens = math.Min(float64(b.limit), b.tokens+rate*float64(now.Sub(b.last)))
+	b.last = now
+}
+
+//wait returns how long until the bucket holds n tokens, or
+//is full if n is more than it holds; the difference is then
+//overdrawn, delaying the calls after it.
+func (b *bucket) wait(n int) time.Duration {
+	need := math.Min(float64(n), float64(b.limit))
+	if b.tokens >= need {
+		return 0
+	}
+	rate := float64(b.limit) / float64(b.window)
+	return time.Duration(math.Ceil((need - b.tokens) / rate))
+}
+
+//NewRateLimiter returns a RateLimiter enforcing limits.
+//Zero limits are not enforced.
+func NewRateLimiter(limits Usage) *RateLimiter {
+	l := new(RateLimiter)
+	l.Seed(TokenUsage{Limits: limits})
+	return l
+}
+
+//Seed sets the limiter's budgets from a CheckUsage result,
+//deducting the hits already counted by the server.
+func (l *RateLimiter) Seed(usage TokenUsage) {
+	now := time.Now()
+	budgets := []struct {
+		name        string
+		limit, hits int
+		window      time.Duration
+	}{
+		{"api/second", usage.Limits.PerSec, usage.Hits.PerSec, time.Second},
+		{"api/hour", usage.Limits.PerHour, usage.Hits.PerHour, time.Hour},
+		{"api/day", usage.Limits.PerDay, usage.Hits.PerDay, 24 * time.Hour},
+		{"hooks/hour", usage.Limits.HooksPerHour, usage.Hits.HooksPerHour, time.Hour},
+		{"confidence/hour", usage.Limits.ConfPerHour, usage.Hits.ConfPerHour, time.Hour},
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets = make(map[string]*bucket)
+	for _, v := range budgets {
+		if v.limit <= 0 {
+			continue
+		}
+		l.buckets[v.name] = &bucket{
+			limit:  v.limit,
+			window: v.window,
+			tokens: math.Max(0, float64(v.limit-v.hits)),
+			last:   now,
+		}
+	}
+}
+
+//Refresh seeds the limiter with the current usage of api's token.
+func (l *RateLimiter) Refresh(c context.Context, api *API) (err error) {
+	usage, err := api.CheckUsage(c)
+	if err != nil {
+		return
+	}
+	l.Seed(usage)
+	return
+}
+
+//RefreshEvery calls Refresh every interval until c is done,
+//reporting failed refreshes to onErr if it isn't nil.
+//It is meant to be run on its own goroutine.
+func (l *RateLimiter) RefreshEvery(c context.Context, api *API, interval time.Duration, onErr func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-t.C:
+			if err := l.Refresh(c, api); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
+
+//Wait takes one token from each of the given budgets, waiting
+//until all of them have one, or failing fast if so configured.
+func (l *RateLimiter) Wait(c context.Context, budgets ...string) error {
+	return l.WaitN(c, 1, budgets...)
+}
+
+//WaitN is Wait for n calls at once, like a batch, which BlockCypher
+//counts as one call per id. Budgets smaller than n are waited on
+//until full, then overdrawn.
+func (l *RateLimiter) WaitN(c context.Context, n int, budgets ...string) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		var delay time.Duration
+		var exhausted string
+		for _, name := range budgets {
+			b := l.buckets[name]
+			if b == nil {
+				continue
+			}
+			b.refill(now)
+			if w := b.wait(n); w > delay {
+				delay, exhausted = w, name
+			}
+		}
+		if delay == 0 {
+			for _, name := range budgets {
+				if b := l.buckets[name]; b != nil {
+					b.tokens -= float64(n)
+				}
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+		if l.FailFast {
+			return &LimitError{Budget: exhausted, Wait: delay}
+		}
+		if deadline, ok := c.Deadline(); ok && now.Add(delay).After(deadline) {
+			return &LimitError{Budget: exhausted, Wait: delay}
+		}
+		if err := sleep(c, delay); err != nil {
+			return err
+		}
+	}
+}
+
+//calls returns how many calls the request counts as.
+func (req *request) calls() int {
+	if req.weight < 1 {
+		return 1
+	}
+	return req.weight
+}
+
+//budgets lists the limiter budgets a request draws from.
+func (req *request) budgets() []string {
+	names := []string{"api/second", "api/hour", "api/day"}
+	path := req.target.Path
+	if req.method == "POST" && strings.HasSuffix(path, "/hooks") {
+		names = append(names, "hooks/hour")
+	}
+	if strings.HasSuffix(path, "/confidence") || req.target.Query().Get("includeConfidence") == "true" {
+		names = append(names, "confidence/hour")
+	}
+	return names
+}
+
+//headroom returns the smallest fraction left of the given
+//budgets, or 1 if none of them is enforced.
+func (l *RateLimiter) headroom(budgets []string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	room := 1.0
+	for _, name := range budgets {
+		if b := l.buckets[name]; b != nil {
+			b.refill(now)
+			room = math.Min(room, b.tokens/float64(b.limit))
+		}
+	}
+	return room
+}
+
+//take draws n tokens from each of the given budgets
+//without waiting, possibly overdrawing them.
+func (l *RateLimiter) take(budgets []string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for _, name := range budgets {
+		if b := l.buckets[name]; b != nil {
+			b.refill(now)
+			b.tokens -= float64(n)
+		}
+	}
+}
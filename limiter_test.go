@@ -0,0 +1,99 @@
+package gobcy
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRateLimiterBudgets(t *testing.T) {
+	tests := []struct {
+		method, target, want string
+	}{
+		{"GET", "https://api.blockcypher.com/v1/btc/main", "api/second api/hour api/day"},
+		{"GET", "https://api.blockcypher.com/v1/btc/main/hooks", "api/second api/hour api/day"},
+		{"POST", "https://api.blockcypher.com/v1/btc/main/hooks", "api/second api/hour api/day hooks/hour"},
+		{"GET", "https://api.blockcypher.com/v1/btc/main/txs/ab/confidence", "api/second api/hour api/day confidence/hour"},
+		{"GET", "https://api.blockcypher.com/v1/btc/main/addrs/1A?includeConfidence=true", "api/second api/hour api/day confidence/hour"},
+	}
+	for _, v := range tests {
+		u, _ := url.Parse(v.target)
+		req, _ := newRequest(v.method, u, nil)
+		if got := strings.Join(req.budgets(), " "); got != v.want {
+			t.Errorf("%v %v draws from %v, expected %v", v.method, v.target, got, v.want)
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	c := context.Background()
+	l := NewRateLimiter(Usage{PerSec: 2, HooksPerHour: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(c, "api/second"); err != nil {
+			t.Fatal("Wait error encountered: ", err)
+		}
+	}
+	if waited := time.Since(start); waited < 400*time.Millisecond {
+		t.Errorf("Expected the third call of 2/s to wait, took %v", waited)
+	}
+	l.FailFast = true
+	err := l.Wait(c, "api/second")
+	var lim *LimitError
+	if !errors.As(err, &lim) || !errors.Is(err, ErrRateLimited) || lim.Budget != "api/second" || lim.Wait <= 0 || lim.Wait > 500*time.Millisecond {
+		t.Errorf("Expected to fail fast on api/second, got %v", err)
+	}
+	//budgets that aren't enforced never block
+	if err = l.Wait(c, "api/hour", "confidence/hour"); err != nil {
+		t.Error("Wait on unenforced budgets error encountered: ", err)
+	}
+	if err = l.Wait(c, "hooks/hour"); err != nil {
+		t.Error("Wait error encountered: ", err)
+	}
+	if err = l.Wait(c, "hooks/hour"); !errors.As(err, &lim) || lim.Budget != "hooks/hour" {
+		t.Errorf("Expected the hooks/hour budget to be exhausted, got %v", err)
+	}
+	//without FailFast, a wait past the deadline fails too
+	l.FailFast = false
+	short, cancel := context.WithTimeout(c, 10*time.Millisecond)
+	defer cancel()
+	if err = l.Wait(short, "hooks/hour"); !errors.As(err, &lim) {
+		t.Errorf("Expected a LimitError past the deadline, got %v", err)
+	}
+}
+
+func TestRateLimiterSeed(t *testing.T) {
+	c := context.Background()
+	l := NewRateLimiter(Usage{})
+	l.FailFast = true
+	l.Seed(TokenUsage{
+		Limits: Usage{PerHour: 10, PerDay: 100},
+		Hits:   Usage{PerHour: 7, PerDay: 100},
+	})
+	if err := l.Wait(c, "api/hour"); err != nil {
+		t.Error("Wait error encountered: ", err)
+	}
+	if err := l.Wait(c, "api/day"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the day's hits to exhaust api/day, got %v", err)
+	}
+	//a batch needs as many calls as it holds...
+	if err := l.WaitN(c, 4, "api/hour"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected a batch of 4 to exceed the 2 calls left, got %v", err)
+	}
+	//...or a full budget, which it then overdraws
+	l = NewRateLimiter(Usage{PerHour: 3})
+	l.FailFast = true
+	if err := l.WaitN(c, 4, "api/hour"); err != nil {
+		t.Error("WaitN error encountered: ", err)
+	}
+	if err := l.Wait(c, "api/hour"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected an overdrawn api/hour, got %v", err)
+	}
+	if room := l.headroom([]string{"api/hour"}); room >= 0 {
+		t.Errorf("Expected no headroom left, got %v", room)
+	}
+}
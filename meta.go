@@ -26,7 +26,7 @@ func (api *API) GetMeta(c context.Context, hash string, kind string, private boo
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &meta)
+	err = api.getResponse(c, u, &meta)
 	return
 }
 
@@ -49,7 +49,7 @@ func (api *API) PutMeta(c context.Context, hash string, kind string, private boo
 	if err != nil {
 		return
 	}
-	err = putResponse(c, u, &meta)
+	err = api.putResponse(c, u, &meta)
 	return
 }
 
@@ -69,6 +69,6 @@ func (api *API) DeleteMeta(c context.Context, hash string, kind string) (err err
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.deleteResponse(c, u)
 	return
 }
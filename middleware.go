@@ -0,0 +1,234 @@
+package gobcy
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//Call describes a single outgoing HTTP request, as seen by
+//Middleware. The token is redacted from every field.
+type Call struct {
+	Method string
+	//Endpoint is the URL path, e.g. "/v1/btc/main/txs/abc".
+	Endpoint string
+	//Params are the query parameters.
+	Params url.Values
+	//URL is the full request URL.
+	URL string
+	//Attempt counts from 1, growing with each retry.
+	Attempt int
+
+	//The fields below are filled in once the request is done.
+	//StatusCode is zero if no response was received.
+	StatusCode int
+	Latency    time.Duration
+	//Err is the request's error: an *APIError for unexpected
+	//statuses, or the transport error.
+	Err error
+}
+
+//Handler performs a Call.
+type Handler func(c context.Context, call *Call) error
+
+//Middleware wraps every outgoing request, e.g. for logging,
+//metrics or tracing:
+//	func(next gobcy.Handler) gobcy.Handler {
+//		return func(c context.Context, call *gobcy.Call) error {
+//			ctx, span := tracer.Start(c, call.Method+" "+call.Endpoint)
+//			defer span.End()
+//			return next(ctx, call)
+//		}
+//	}
+//A Middleware can also fail a call without sending it by
+//returning an error instead of calling next.
+type Middleware func(next Handler) Handler
+
+//newCall describes the given attempt of req.
+func (api *API) newCall(req *request, attempt int) *Call {
+	u := api.redactURL(req.target)
+	return &Call{
+		Method:   req.method,
+		Endpoint: u.Path,
+		Params:   u.Query(),
+		URL:      u.String(),
+		Attempt:  attempt,
+	}
+}
+
+//redactURL returns a copy of u without the token: its "token"
+//parameter is dropped and any path segment holding a token,
+//like the one of "tokens/<token>", is replaced.
+func (api *API) redactURL(u *url.URL) *url.URL {
+	clean := *u
+	values := clean.Query()
+	if values.Has("token") {
+		values.Del("token")
+		clean.RawQuery = values.Encode()
+	}
+	secrets := api.secrets()
+	segments := strings.Split(clean.Path, "/")
+	for i, v := range segments {
+		if v != "" && slices.Contains(secrets, v) {
+			segments[i] = "REDACTED"
+			clean.Path, clean.RawPath = strings.Join(segments, "/"), ""
+		}
+	}
+	return &clean
+}
+
+//attempt sends req once through the middleware chain, and
+//returns the response if it was successful.
+func (api *API) attempt(c context.Context, req *request, n int) (resp *http.Response, err error) {
+	h := func(c context.Context, call *Call) (err error) {
+		start := time.Now()
+		r, err := api.send(c, req)
+		call.Latency = time.Since(start)
+		if err == nil {
+			call.StatusCode = r.StatusCode
+			if okStatus(req.method, r.StatusCode) {
+				resp = r
+			} else {
+				err = respErrorMaker(r, call.Endpoint, api.redactString)
+				r.Body.Close()
+			}
+		}
+		call.Err = err
+		return
+	}
+	for i := len(api.Middleware) - 1; i >= 0; i-- {
+		h = api.Middleware[i](h)
+	}
+	if err = h(c, api.newCall(req, n)); err != nil && resp != nil {
+		resp.Body.Close()
+		resp = nil
+	}
+	return
+}
+
+//LogMiddleware logs every request to logger: successful ones
+//at debug level, failed ones at warn level.
+func LogMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(c context.Context, call *Call) error {
+			err := next(c, call)
+			level := slog.LevelDebug
+			attrs := []slog.Attr{
+				slog.String("method", call.Method),
+				slog.String("endpoint", call.Endpoint),
+				slog.Int("attempt", call.Attempt),
+				slog.Int("status", call.StatusCode),
+				slog.Duration("latency", call.Latency),
+			}
+			if err != nil {
+				level = slog.LevelWarn
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(c, level, "gobcy request", attrs...)
+			return err
+		}
+	}
+}
+
+//redactedError hides the token from the message
+//of the error it wraps.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+//redactError scrubs the token from err: from the URL of
+//a *url.Error, and from any other error message.
+func (api *API) redactError(err error) error {
+	secrets := api.secrets()
+	if err == nil || len(secrets) == 0 {
+		return err
+	}
+	if ue, ok := err.(*url.Error); ok {
+		clean := *ue
+		if u, perr := url.Parse(ue.URL); perr == nil {
+			clean.URL = api.redactURL(u).String()
+		} else {
+			clean.URL = api.redactString(ue.URL)
+		}
+		err = &clean
+	}
+	if msg := api.redactString(err.Error()); msg != err.Error() {
+		err = &redactedError{msg, err}
+	}
+	return err
+}
+
+//secrets lists the tokens used by api.
+func (api *API) secrets() (tokens []string) {
+	if api.Token != "" {
+		tokens = append(tokens, api.Token)
+	}
+	if api.Tokens != nil {
+		for _, v := range api.Tokens.Tokens() {
+			if v != "" {
+				tokens = append(tokens, v)
+			}
+		}
+	}
+	return
+}
+
+//redactString replaces the tokens used by api in s, wherever
+//they stand as a whole word, so that a short token doesn't
+//mangle the rest of s.
+func (api *API) redactString(s string) string {
+	for _, token := range api.secrets() {
+		s = replaceWord(s, token, "REDACTED")
+	}
+	return s
+}
+
+//replaceWord replaces the occurrences of word in s that aren't
+//part of a longer run of letters, digits, '-' and '_'.
+func replaceWord(s, word, with string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			break
+		}
+		end := i + len(word)
+		if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			b.WriteString(s[:i])
+			b.WriteString(with)
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+//isWordByte reports whether c may be part of a token.
+func isWordByte(c byte) bool {
+	return c == '-' || c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+//stripToken removes the token parameter from a URL returned
+//by the server, such as a next page link.
+func stripToken(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || !u.Query().Has("token") {
+		return raw
+	}
+	values := u.Query()
+	values.Del("token")
+	u.RawQuery = values.Encode()
+	return u.String()
+}
@@ -0,0 +1,123 @@
+package gobcy
+
+import (
+	"errors"
+	"maps"
+)
+
+//Network describes a coin/chain supported by BlockCypher
+//and its parameters.
+type Network struct {
+	Coin, Chain string
+	//Name is a human readable name, e.g. "Bitcoin Testnet3".
+	Name string
+	//PubKeyHashVersion, ScriptHashVersion and WIFVersion are
+	//the Base58Check version bytes of P2PKH addresses, P2SH
+	//addresses and WIF private keys.
+	PubKeyHashVersion, ScriptHashVersion, WIFVersion byte
+	//Bech32HRP is the human readable part of native segwit
+	//addresses, or "" if the network has no segwit.
+	Bech32HRP string
+	//BIP44CoinType is the coin_type level of BIP44 paths.
+	BIP44CoinType uint32
+	//Decimals is the number of decimal places of Unit,
+	//i.e. Unit is 10^Decimals base units.
+	Decimals int
+	//Unit is the main unit, e.g. "BTC", and BaseUnit the
+	//smallest one, e.g. "satoshi", in which the API counts.
+	Unit, BaseUnit string
+	//Units maps every unit name to its decimal places,
+	//e.g. "mBTC" to 5 and "sat" to 0.
+	Units map[string]int
+	//Account is set for account-based networks (Ethereum),
+	//which have no version bytes.
+	Account bool
+	//Faucet is set for test networks with a faucet.
+	Faucet bool
+}
+
+//String returns the network as "coin/chain".
+func (n Network) String() string {
+	return n.Coin + "/" + n.Chain
+}
+
+//btcUnits are the units of Bitcoin-like networks, given
+//their main unit and base unit names.
+func btcUnits(unit, base string, aliases ...string) map[string]int {
+	units := map[string]int{unit: 8, "m" + unit: 5, base: 0}
+	for _, v := range aliases {
+		units[v] = 0
+	}
+	return units
+}
+
+//ethUnits are the units of Ethereum networks.
+func ethUnits() map[string]int {
+	return map[string]int{"ETH": 18, "ether": 18, "finney": 15, "szabo": 12, "gwei": 9, "mwei": 6, "kwei": 3, "wei": 0}
+}
+
+//networks is the registry behind Networks and LookupNetwork.
+var networks = []Network{
+	{Coin: "btc", Chain: "main", Name: "Bitcoin",
+		PubKeyHashVersion: 0x00, ScriptHashVersion: 0x05, WIFVersion: 0x80, Bech32HRP: "bc", BIP44CoinType: 0,
+		Decimals: 8, Unit: "BTC", BaseUnit: "satoshi", Units: btcUnits("BTC", "satoshi", "sat", "sats")},
+	{Coin: "btc", Chain: "test3", Name: "Bitcoin Testnet3",
+		PubKeyHashVersion: 0x6f, ScriptHashVersion: 0xc4, WIFVersion: 0xef, Bech32HRP: "tb", BIP44CoinType: 1,
+		Decimals: 8, Unit: "BTC", BaseUnit: "satoshi", Units: btcUnits("BTC", "satoshi", "sat", "sats"), Faucet: true},
+	{Coin: "bcy", Chain: "test", Name: "BlockCypher Testnet",
+		PubKeyHashVersion: 0x1b, ScriptHashVersion: 0x1f, WIFVersion: 0x49, BIP44CoinType: 1,
+		Decimals: 8, Unit: "BCY", BaseUnit: "satoshi", Units: btcUnits("BCY", "satoshi", "sat", "sats"), Faucet: true},
+	{Coin: "ltc", Chain: "main", Name: "Litecoin",
+		PubKeyHashVersion: 0x30, ScriptHashVersion: 0x32, WIFVersion: 0xb0, Bech32HRP: "ltc", BIP44CoinType: 2,
+		Decimals: 8, Unit: "LTC", BaseUnit: "litoshi", Units: btcUnits("LTC", "litoshi")},
+	{Coin: "doge", Chain: "main", Name: "Dogecoin",
+		PubKeyHashVersion: 0x1e, ScriptHashVersion: 0x16, WIFVersion: 0x9e, BIP44CoinType: 3,
+		Decimals: 8, Unit: "DOGE", BaseUnit: "koinu", Units: btcUnits("DOGE", "koinu")},
+	{Coin: "dash", Chain: "main", Name: "Dash",
+		PubKeyHashVersion: 0x4c, ScriptHashVersion: 0x10, WIFVersion: 0xcc, BIP44CoinType: 5,
+		Decimals: 8, Unit: "DASH", BaseUnit: "duff", Units: btcUnits("DASH", "duff")},
+	{Coin: "eth", Chain: "main", Name: "Ethereum", BIP44CoinType: 60,
+		Decimals: 18, Unit: "ETH", BaseUnit: "wei", Units: ethUnits(), Account: true},
+	{Coin: "beth", Chain: "test", Name: "BlockCypher Ethereum Testnet", BIP44CoinType: 1,
+		Decimals: 18, Unit: "ETH", BaseUnit: "wei", Units: ethUnits(), Account: true, Faucet: true},
+}
+
+//Networks returns every network in the registry.
+func Networks() []Network {
+	list := make([]Network, len(networks))
+	for i, v := range networks {
+		v.Units = maps.Clone(v.Units)
+		list[i] = v
+	}
+	return list
+}
+
+//LookupNetwork returns the registered network for coin/chain.
+func LookupNetwork(coin, chain string) (n Network, ok bool) {
+	for _, v := range networks {
+		if v.Coin == coin && v.Chain == chain {
+			v.Units = maps.Clone(v.Units)
+			return v, true
+		}
+	}
+	return
+}
+
+//Network returns the registered network of the API's Coin/Chain.
+func (api *API) Network() (n Network, err error) {
+	n, ok := LookupNetwork(api.Coin, api.Chain)
+	if !ok {
+		err = errors.New("API: unknown coin/chain \"" + api.Coin + "/" + api.Chain + "\"")
+	}
+	return
+}
+
+//Validate checks the API's configuration: a registered
+//Coin/Chain and a usable BaseURL.
+func (api *API) Validate() (err error) {
+	if _, err = api.Network(); err != nil {
+		return
+	}
+	_, err = api.resolve("")
+	return
+}
@@ -0,0 +1,39 @@
+package gobcy
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestNetwork(t *testing.T) {
+	api := API{Token: "test-token", Coin: "bcy", Chain: "test"}
+	if err := api.Validate(); err != nil {
+		t.Error("Validate error encountered: ", err)
+	}
+	if len(Networks()) != 8 {
+		t.Errorf("Expected 8 networks, got %v", len(Networks()))
+	}
+	btc, ok := LookupNetwork("btc", "main")
+	if !ok || btc.Bech32HRP != "bc" || btc.Units["sat"] != 0 || btc.Units["BTC"] != 8 || btc.String() != "btc/main" {
+		t.Errorf("Unexpected btc/main network %+v", btc)
+	}
+	btc.Units["BTC"] = 0
+	if btc, _ = LookupNetwork("btc", "main"); btc.Units["BTC"] != 8 {
+		t.Error("Changing a looked up Network changed the registry")
+	}
+	api.Coin, api.Chain = "btc", "mian"
+	if err := api.Validate(); err == nil {
+		t.Error("Expected error from Validate with an unknown chain, did not receive one")
+	}
+	if _, err := NewCheckedAPI("test-token", "btc", "mian", nil); err == nil {
+		t.Error("Expected error from NewCheckedAPI with an unknown chain, did not receive one")
+	}
+	if _, err := NewCheckedAPI("test-token", "btc", "test3", nil); err != nil {
+		t.Error("NewCheckedAPI error encountered: ", err)
+	}
+	api.Chain = "main"
+	if _, err := api.Faucet(context.Background(), AddrKeychain{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"}, 1000); err == nil {
+		t.Error("Expected error from Faucet on a main network, did not receive one")
+	}
+}
@@ -0,0 +1,268 @@
+package gobcy
+
+import (
+	"errors"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+//AddrOptions are the query parameters of the Address API,
+//used by GetAddrOpts, GetAddrBalOpts and GetAddrFullOpts.
+//Zero fields are left to BlockCypher's defaults.
+type AddrOptions struct {
+	//Before and After only return transactions below/above
+	//these block heights.
+	Before, After int
+	//Limit caps the number of TXRefs (up to 2000) or
+	//TXs (up to 50, for GetAddrFullOpts) returned.
+	Limit int
+	//UnspentOnly only returns unspent outputs.
+	UnspentOnly bool
+	//IncludeScript includes the raw scripts of the outputs.
+	IncludeScript bool
+	//IncludeConfidence includes the confidence of
+	//unconfirmed transactions.
+	IncludeConfidence bool
+	//ConfirmationsMin only returns transactions, and counts
+	//balances, with at least this many confirmations.
+	ConfirmationsMin int
+	//ConfirmationsMax drops transactions with more than this
+	//many confirmations. BlockCypher has no such parameter, so
+	//it is applied to the returned TXRefs/TXs and doesn't change
+	//balances. Paging still follows the unfiltered page: HasMore
+	//and the next "before" height describe what the server sent,
+	//so a filtered page may come back short, or even empty,
+	//with more to fetch.
+	ConfirmationsMax int
+}
+
+//params validates the options and returns them as URL
+//parameters, for an endpoint returning up to maxLimit items.
+func (o *AddrOptions) params(maxLimit int) (params map[string]string, err error) {
+	params = make(map[string]string)
+	if o == nil {
+		return
+	}
+	switch {
+	case o.Before < 0 || o.After < 0:
+		err = errors.New("AddrOptions: Before and After must not be negative")
+	case o.Before > 0 && o.After >= o.Before:
+		err = errors.New("AddrOptions: After must be lower than Before")
+	case o.Limit < 0 || o.Limit > maxLimit:
+		err = errors.New("AddrOptions: Limit must be 0 (default) or 1.." + strconv.Itoa(maxLimit))
+	case o.ConfirmationsMin < 0 || o.ConfirmationsMax < 0:
+		err = errors.New("AddrOptions: ConfirmationsMin and ConfirmationsMax must not be negative")
+	case o.ConfirmationsMax > 0 && o.ConfirmationsMin > o.ConfirmationsMax:
+		err = errors.New("AddrOptions: ConfirmationsMin must not exceed ConfirmationsMax")
+	}
+	if err != nil {
+		return
+	}
+	setInt(params, "before", o.Before)
+	setInt(params, "after", o.After)
+	setInt(params, "limit", o.Limit)
+	setInt(params, "confirmations", o.ConfirmationsMin)
+	setBool(params, "unspentOnly", o.UnspentOnly)
+	setBool(params, "includeScript", o.IncludeScript)
+	setBool(params, "includeConfidence", o.IncludeConfidence)
+	return
+}
+
+//filter applies ConfirmationsMax to addr.
+func (o *AddrOptions) filter(addr *Addr) {
+	if o == nil || o.ConfirmationsMax == 0 {
+		return
+	}
+	keep := func(refs []TXRef) (kept []TXRef) {
+		for _, v := range refs {
+			if v.Confirmations <= o.ConfirmationsMax {
+				kept = append(kept, v)
+			}
+		}
+		return
+	}
+	addr.TXRefs = keep(addr.TXRefs)
+	addr.UnconfirmedTXRefs = keep(addr.UnconfirmedTXRefs)
+	var txs []TX
+	for _, v := range addr.TXs {
+		if v.Confirmations <= o.ConfirmationsMax {
+			txs = append(txs, v)
+		}
+	}
+	addr.TXs = txs
+}
+
+//TXOptions are the query parameters of GetTXOpts.
+//Zero fields are left to BlockCypher's defaults.
+type TXOptions struct {
+	//Limit caps the number of inputs and outputs
+	//returned, up to 100.
+	Limit int
+	//InStart and OutStart are the first input and
+	//output returned, for paging.
+	InStart, OutStart int
+	//IncludeHex includes the raw transaction.
+	IncludeHex bool
+	//IncludeConfidence includes the confidence
+	//of an unconfirmed transaction.
+	IncludeConfidence bool
+}
+
+//params validates the options and returns them as URL parameters.
+func (o *TXOptions) params() (params map[string]string, err error) {
+	params = make(map[string]string)
+	if o == nil {
+		return
+	}
+	switch {
+	case o.Limit < 0 || o.Limit > 100:
+		err = errors.New("TXOptions: Limit must be 0 (default) or 1..100")
+	case o.InStart < 0 || o.OutStart < 0:
+		err = errors.New("TXOptions: InStart and OutStart must not be negative")
+	}
+	if err != nil {
+		return
+	}
+	setInt(params, "limit", o.Limit)
+	setInt(params, "instart", o.InStart)
+	setInt(params, "outstart", o.OutStart)
+	setBool(params, "includeHex", o.IncludeHex)
+	setBool(params, "includeConfidence", o.IncludeConfidence)
+	return
+}
+
+//BlockOptions are the query parameters of GetBlockOpts.
+//Zero fields are left to BlockCypher's defaults.
+type BlockOptions struct {
+	//TXStart is the first TXid returned, for paging.
+	TXStart int
+	//Limit caps the number of TXids returned, up to 500.
+	Limit int
+}
+
+//params validates the options and returns them as URL parameters.
+func (o *BlockOptions) params() (params map[string]string, err error) {
+	params = make(map[string]string)
+	if o == nil {
+		return
+	}
+	switch {
+	case o.TXStart < 0:
+		err = errors.New("BlockOptions: TXStart must not be negative")
+	case o.Limit < 0 || o.Limit > 500:
+		err = errors.New("BlockOptions: Limit must be 0 (default) or 1..500")
+	}
+	if err != nil {
+		return
+	}
+	setInt(params, "txstart", o.TXStart)
+	setInt(params, "limit", o.Limit)
+	return
+}
+
+//HDAddrOptions are the query parameters of GetAddrHDWalletOpts.
+//Nil fields don't filter the addresses returned.
+type HDAddrOptions struct {
+	//Used only returns addresses that were (or weren't) used.
+	Used *bool
+	//ZeroBalance only returns addresses with (or without)
+	//a zero balance.
+	ZeroBalance *bool
+	//SubchainIndex only returns addresses of this subchain.
+	SubchainIndex *int
+}
+
+//params validates the options and returns them as URL parameters.
+func (o *HDAddrOptions) params() (params map[string]string, err error) {
+	params = make(map[string]string)
+	if o == nil {
+		return
+	}
+	if o.SubchainIndex != nil && *o.SubchainIndex < 0 {
+		err = errors.New("HDAddrOptions: SubchainIndex must not be negative")
+		return
+	}
+	if o.Used != nil {
+		params["used"] = strconv.FormatBool(*o.Used)
+	}
+	if o.ZeroBalance != nil {
+		params["zerobalance"] = strconv.FormatBool(*o.ZeroBalance)
+	}
+	if o.SubchainIndex != nil {
+		params["subchain_index"] = strconv.Itoa(*o.SubchainIndex)
+	}
+	return
+}
+
+//setInt sets a parameter to v, unless it is zero.
+func setInt(params map[string]string, key string, v int) {
+	if v != 0 {
+		params[key] = strconv.Itoa(v)
+	}
+}
+
+//setBool sets a parameter to true, if v is.
+func setBool(params map[string]string, key string, v bool) {
+	if v {
+		params[key] = "true"
+	}
+}
+
+//GetAddrBalOpts is GetAddrBal with typed options.
+func (api *API) GetAddrBalOpts(c context.Context, hash string, opts *AddrOptions) (addr Addr, err error) {
+	params, err := opts.params(2000)
+	if err != nil {
+		return
+	}
+	return api.GetAddrBal(c, hash, params)
+}
+
+//GetAddrOpts is GetAddr with typed options.
+func (api *API) GetAddrOpts(c context.Context, hash string, opts *AddrOptions) (addr Addr, err error) {
+	params, err := opts.params(2000)
+	if err != nil {
+		return
+	}
+	addr, err = api.GetAddr(c, hash, params)
+	opts.filter(&addr)
+	return
+}
+
+//GetAddrFullOpts is GetAddrFull with typed options.
+func (api *API) GetAddrFullOpts(c context.Context, hash string, opts *AddrOptions) (addr Addr, err error) {
+	params, err := opts.params(50)
+	if err != nil {
+		return
+	}
+	addr, err = api.GetAddrFull(c, hash, params)
+	opts.filter(&addr)
+	return
+}
+
+//GetTXOpts is GetTX with typed options.
+func (api *API) GetTXOpts(c context.Context, hash string, opts *TXOptions) (tx TX, err error) {
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	return api.GetTX(c, hash, params)
+}
+
+//GetBlockOpts is GetBlock with typed options.
+func (api *API) GetBlockOpts(c context.Context, height int, hash string, opts *BlockOptions) (block Block, err error) {
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	return api.GetBlock(c, height, hash, params)
+}
+
+//GetAddrHDWalletOpts is GetAddrHDWallet with typed options.
+func (api *API) GetAddrHDWalletOpts(c context.Context, name string, opts *HDAddrOptions) (addrs HDWallet, err error) {
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	return api.GetAddrHDWallet(c, name, params)
+}
@@ -14,31 +14,33 @@ func (api *API) CreatePayFwd(c context.Context, payment PayFwd) (result PayFwd,
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &payment, &result)
+	err = api.createOwned(c, u, &payment, &result, func() string { return result.ID })
 	return
 }
 
 //ListPayFwds returns a PayFwds slice
-//associated with your API.Token.
+//associated with your API.Token, or with
+//any token of API.Tokens.
 func (api *API) ListPayFwds(c context.Context) (payments []PayFwd, err error) {
 	u, err := api.buildURL("/payments", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &payments)
+	payments, err = listOwned(c, api, u, func(v PayFwd) string { return v.ID })
 	return
 }
 
 //ListPayFwdsPage returns a PayFwds slice
 //associated with your API.Token, starting at the start index.
 //Useful for paging past the 200 payment forward limit.
+//With a TokenPool, start applies to each token's list.
 func (api *API) ListPayFwdsPage(c context.Context, start int) (payments []PayFwd, err error) {
 	params := map[string]string{"start": strconv.Itoa(start)}
 	u, err := api.buildURL("/payments", params)
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &payments)
+	payments, err = listOwned(c, api, u, func(v PayFwd) string { return v.ID })
 	return
 }
 
@@ -48,7 +50,11 @@ func (api *API) GetPayFwd(c context.Context, id string) (payment PayFwd, err err
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &payment)
+	req, err := newRequest("GET", u, nil)
+	if err != nil {
+		return
+	}
+	err = api.ownedResponse(c, id, req, &payment)
 	return
 }
 
@@ -59,6 +65,6 @@ func (api *API) DeletePayFwd(c context.Context, id string) (err error) {
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.deleteOwned(c, id, u)
 	return
 }
@@ -0,0 +1,109 @@
+package gobcy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//RetryPolicy describes how failed calls are repeated.
+//Rate-limited calls (HTTP 429) are retried for every method,
+//since the server rejected them before doing any work. Calls
+//that failed with a 5xx status or a network error are only
+//retried when repeating them is safe: GETs, PUTs and DELETEs,
+//plus the transaction POSTs (NewTX, SendTX, PushTX, DecodeTX).
+//Creating hooks, payment forwards, wallets or addresses is
+//never repeated after such errors.
+//
+//Delays grow exponentially from BaseDelay up to MaxDelay, with
+//full jitter. A Retry-After header sent by the server is used
+//as a lower bound, and no attempt is made if the delay would
+//run past the context's deadline.
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts, including
+	//the first one. Values below 2 disable retrying.
+	MaxAttempts int
+	//BaseDelay is the delay cap before the first retry,
+	//500ms if zero.
+	BaseDelay time.Duration
+	//MaxDelay caps the exponential backoff, 30s if zero.
+	MaxDelay time.Duration
+}
+
+//NewRetryPolicy returns a RetryPolicy making up to
+//maxAttempts attempts with the default delays.
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: maxAttempts}
+}
+
+//backoff decides whether a call that failed with err on
+//the given attempt should be repeated, and after how long.
+func (p *RetryPolicy) backoff(c context.Context, attempt int, err error, idempotent bool) (delay time.Duration, retry bool) {
+	if p == nil || attempt >= p.MaxAttempts || c.Err() != nil {
+		return
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			retry = true
+		case idempotent && isTransientStatus(apiErr.StatusCode):
+			retry = true
+		}
+	} else {
+		//network errors: the request may or may not have
+		//reached the server
+		retry = idempotent
+	}
+	if !retry {
+		return
+	}
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	ceiling := max
+	if shift := attempt - 1; shift < 32 && base<<shift < max && base<<shift > 0 {
+		ceiling = base << shift
+	}
+	delay = rand.N(ceiling + 1)
+	if apiErr != nil && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	if deadline, ok := c.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+	return
+}
+
+//isTransientStatus reports whether a server error
+//is likely to go away on its own.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+//sleep waits for d, or until c is done.
+func sleep(c context.Context, d time.Duration) error {
+	if d <= 0 {
+		return c.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
@@ -0,0 +1,333 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//SchemaError is returned in Strict mode when a response doesn't
+//match the types it is decoded into: fields the types don't know,
+//or values of the wrong JSON type. Whatever could be decoded is
+//still filled in.
+type SchemaError struct {
+	//Endpoint is the URL path of the request.
+	Endpoint string
+	Issues   []SchemaIssue
+	//Err is the decoding error, if decoding failed outright.
+	Err error
+}
+
+//SchemaIssue is a single mismatch found by Strict mode.
+type SchemaIssue struct {
+	//Path locates the value, e.g. "txs[0].inputs[1].output_value".
+	Path    string
+	Problem string
+}
+
+func (e *SchemaError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, v := range e.Issues {
+		issues[i] = v.Path + ": " + v.Problem
+	}
+	return "SchemaError: " + strconv.Itoa(len(e.Issues)) + " issue(s) decoding " + e.Endpoint + ": " + strings.Join(issues, "; ")
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+//decode unmarshals data, the response to req, into decTarget,
+//checking it against decTarget's type in Strict mode.
+func (api *API) decode(req *request, data []byte, decTarget interface{}) error {
+	err := json.Unmarshal(data, decTarget)
+	if !api.Strict {
+		return err
+	}
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if dec.Decode(&tree) != nil {
+		return err
+	}
+	var issues []SchemaIssue
+	checkSchema(tree, reflect.TypeOf(decTarget), "", &issues)
+	if len(issues) == 0 {
+		return err
+	}
+	return &SchemaError{Endpoint: api.redactURL(req.target).Path, Issues: issues, Err: err}
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	bigIntType      = reflect.TypeOf(big.Int{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	//extraTypes decode their unknown fields into Extra, but
+	//are otherwise checked field by field
+	extraTypes = map[reflect.Type]bool{
+		reflect.TypeOf(TX{}):         true,
+		reflect.TypeOf(Addr{}):       true,
+		reflect.TypeOf(Block{}):      true,
+		reflect.TypeOf(Blockchain{}): true,
+	}
+)
+
+//checkSchema compares v, a JSON value decoded with UseNumber,
+//to the type t it is decoded into, appending the mismatches.
+func checkSchema(v interface{}, t reflect.Type, path string, issues *[]SchemaIssue) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if v == nil || t == rawMessageType || t.Kind() == reflect.Interface {
+		return
+	}
+	mismatch := func(want string) {
+		*issues = append(*issues, SchemaIssue{strings.TrimPrefix(path, "."), "expected " + want + ", got " + jsonKind(v)})
+	}
+	switch {
+	case t == timeType:
+		if _, ok := v.(string); !ok {
+			mismatch("a timestamp string")
+		}
+		return
+	case t == bigIntType:
+		if n, ok := v.(json.Number); !ok || !isInteger(n) {
+			mismatch("an integer")
+		}
+		return
+	case !extraTypes[t] && reflect.PointerTo(t).Implements(unmarshalerType):
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		fields := jsonFields(t)
+		for k, val := range obj {
+			ft, ok := fields[strings.ToLower(k)]
+			if !ok {
+				*issues = append(*issues, SchemaIssue{strings.TrimPrefix(path+"."+k, "."), "unknown field"})
+				continue
+			}
+			checkSchema(val, ft, path+"."+k, issues)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		for k, val := range obj {
+			checkSchema(val, t.Elem(), path+"."+k, issues)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := v.([]interface{})
+		if !ok {
+			mismatch("an array")
+			return
+		}
+		for i, val := range list {
+			checkSchema(val, t.Elem(), path+"["+strconv.Itoa(i)+"]", issues)
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			mismatch("a string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			mismatch("a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.(json.Number)
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if !ok || err != nil || reflect.Zero(t).OverflowInt(i) {
+			mismatch("an integer fitting " + t.Kind().String())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		if !ok || err != nil || reflect.Zero(t).OverflowUint(u) {
+			mismatch("an integer fitting " + t.Kind().String())
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(json.Number); !ok {
+			mismatch("a number")
+		}
+	}
+}
+
+//jsonKind describes a JSON value for a SchemaIssue.
+func jsonKind(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return "string " + strconv.Quote(v)
+	case json.Number:
+		return "number " + string(v)
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+//isInteger reports whether n is written as an integer.
+func isInteger(n json.Number) bool {
+	_, ok := new(big.Int).SetString(string(n), 10)
+	return ok
+}
+
+//fieldCache holds the jsonFields of struct types.
+var fieldCache sync.Map
+
+//jsonFields maps the lower-cased JSON names of the fields of the
+//struct type t, including promoted ones (also through embedded
+//pointers), to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		//embedded structs, or pointers to them, are
+		//flattened like encoding/json does
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for k, v := range jsonFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+//extraFields returns the fields of the JSON object data
+//that don't map to a field of the struct type t.
+func extraFields(data []byte, t reflect.Type) (extra map[string]json.RawMessage, err error) {
+	var obj map[string]json.RawMessage
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return
+	}
+	fields := jsonFields(t)
+	for k, v := range obj {
+		if _, ok := fields[strings.ToLower(k)]; ok {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[k] = v
+	}
+	return
+}
+
+//marshalExtra marshals v, adding the fields of extra
+//it doesn't already have.
+func marshalExtra(v interface{}, extra map[string]json.RawMessage) (data []byte, err error) {
+	if data, err = json.Marshal(v); err != nil || len(extra) == 0 {
+		return
+	}
+	var obj map[string]json.RawMessage
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return
+	}
+	for k, v := range extra {
+		if _, ok := obj[k]; !ok {
+			obj[k] = v
+		}
+	}
+	return json.Marshal(obj)
+}
+
+//UnmarshalJSON decodes a TX, keeping unknown fields in Extra.
+func (tx *TX) UnmarshalJSON(data []byte) (err error) {
+	type plain TX
+	if err = json.Unmarshal(data, (*plain)(tx)); err != nil {
+		return
+	}
+	tx.Extra, err = extraFields(data, reflect.TypeOf(*tx))
+	return
+}
+
+//MarshalJSON encodes a TX, including the fields in Extra.
+func (tx TX) MarshalJSON() ([]byte, error) {
+	type plain TX
+	return marshalExtra((*plain)(&tx), tx.Extra)
+}
+
+//UnmarshalJSON decodes an Addr, keeping unknown fields in Extra.
+func (addr *Addr) UnmarshalJSON(data []byte) (err error) {
+	type plain Addr
+	if err = json.Unmarshal(data, (*plain)(addr)); err != nil {
+		return
+	}
+	addr.Extra, err = extraFields(data, reflect.TypeOf(*addr))
+	return
+}
+
+//MarshalJSON encodes an Addr, including the fields in Extra.
+func (addr Addr) MarshalJSON() ([]byte, error) {
+	type plain Addr
+	return marshalExtra((*plain)(&addr), addr.Extra)
+}
+
+//UnmarshalJSON decodes a Block, keeping unknown fields in Extra.
+func (block *Block) UnmarshalJSON(data []byte) (err error) {
+	type plain Block
+	if err = json.Unmarshal(data, (*plain)(block)); err != nil {
+		return
+	}
+	block.Extra, err = extraFields(data, reflect.TypeOf(*block))
+	return
+}
+
+//MarshalJSON encodes a Block, including the fields in Extra.
+func (block Block) MarshalJSON() ([]byte, error) {
+	type plain Block
+	return marshalExtra((*plain)(&block), block.Extra)
+}
+
+//UnmarshalJSON decodes a Blockchain, keeping unknown fields in Extra.
+func (chain *Blockchain) UnmarshalJSON(data []byte) (err error) {
+	type plain Blockchain
+	if err = json.Unmarshal(data, (*plain)(chain)); err != nil {
+		return
+	}
+	chain.Extra, err = extraFields(data, reflect.TypeOf(*chain))
+	return
+}
+
+//MarshalJSON encodes a Blockchain, including the fields in Extra.
+func (chain Blockchain) MarshalJSON() ([]byte, error) {
+	type plain Blockchain
+	return marshalExtra((*plain)(&chain), chain.Extra)
+}
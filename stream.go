@@ -0,0 +1,348 @@
+package gobcy
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+//StreamAddrFull calls fn with each TX of the given address,
+//newest first, like NewAddrFullCursor, decoding them one by one
+//as the response arrives so a whole page is never held in
+//memory. Returning an error from fn stops the stream and returns
+//it. opts may be nil; its Limit sets the page size.
+func (api *API) StreamAddrFull(c context.Context, hash string, opts *AddrOptions, fn func(TX) error) (err error) {
+	s, err := api.addrFullStream(hash, opts)
+	if err != nil {
+		return
+	}
+	return s.each(c, fn)
+}
+
+//StreamAddrTXRefs is StreamAddrFull for the TXRefs of the
+//given address, unconfirmed ones first.
+func (api *API) StreamAddrTXRefs(c context.Context, hash string, opts *AddrOptions, fn func(TXRef) error) (err error) {
+	s, err := api.addrTXRefStream(hash, opts)
+	if err != nil {
+		return
+	}
+	return s.each(c, fn)
+}
+
+//StreamBlock is GetBlock for blocks with many transactions: it
+//calls fn with each transaction hash of the block with the given
+//height or hash as it is decoded, fetching further pages as
+//needed, and returns the block without its TXids. Returning an
+//error from fn stops the stream and returns it. opts may be nil;
+//its Limit sets the page size.
+func (api *API) StreamBlock(c context.Context, height int, hash string, opts *BlockOptions, fn func(txid string) error) (block Block, err error) {
+	if height != 0 && hash != "" {
+		err = errors.New("Func StreamBlock: Cannot send both height and hash")
+		return
+	}
+	params, err := opts.params()
+	if err != nil {
+		return
+	}
+	id := hash
+	if hash == "" {
+		id = strconv.Itoa(height)
+	}
+	start := 0
+	if opts != nil {
+		start = opts.TXStart
+	}
+	for first := true; ; first = false {
+		u, err := api.buildURL("/blocks/"+id, params)
+		if err != nil {
+			return Block{}, err
+		}
+		var page Block
+		n := 0
+		err = api.streamObject(c, u, map[string]func(*json.Decoder) error{
+			"txids": func(dec *json.Decoder) error {
+				var txid string
+				if err := dec.Decode(&txid); err != nil {
+					return err
+				}
+				n++
+				return fn(txid)
+			},
+		}, &page)
+		if err != nil {
+			return Block{}, err
+		}
+		if first {
+			block = page
+			block.NextTXs = ""
+		}
+		if page.NextTXs == "" || n == 0 {
+			return block, nil
+		}
+		//stick to this block, even if its height is reorged
+		if page.Hash != "" {
+			id = page.Hash
+		}
+		start += n
+		params["txstart"] = strconv.Itoa(start)
+	}
+}
+
+//StreamBlockTXids is StreamBlock for callers that only
+//need the transaction hashes.
+func (api *API) StreamBlockTXids(c context.Context, height int, hash string, opts *BlockOptions, fn func(txid string) error) (err error) {
+	_, err = api.StreamBlock(c, height, hash, opts, fn)
+	return
+}
+
+//addrFullStream returns the stream of the full TXs
+//of the given address.
+func (api *API) addrFullStream(hash string, opts *AddrOptions) (*addrStream[TX], error) {
+	return newAddrStream(api, "/addrs/"+hash+"/full", 50, opts, []string{"txs"},
+		func(tx TX) (int, string, int) { return tx.BlockHeight, tx.Hash, tx.Confirmations })
+}
+
+//addrTXRefStream returns the stream of the TXRefs
+//of the given address.
+func (api *API) addrTXRefStream(hash string, opts *AddrOptions) (*addrStream[TXRef], error) {
+	return newAddrStream(api, "/addrs/"+hash, 2000, opts, []string{"unconfirmed_txrefs", "txrefs"},
+		func(ref TXRef) (int, string, int) {
+			return ref.BlockHeight, ref.TXHash + ":" + strconv.Itoa(ref.TXInputN) + ":" + strconv.Itoa(ref.TXOutputN), ref.Confirmations
+		})
+}
+
+//addrStream walks the elements of the given arrays of the Address
+//API endpoint at path, page by page, decoding each one as it
+//arrives. key returns an element's block height, identity and
+//confirmations.
+type addrStream[T any] struct {
+	api    *API
+	path   string
+	opts   *AddrOptions
+	fields []string
+	key    func(T) (int, string, int)
+	pager  *addrPager
+}
+
+//newAddrStream returns an addrStream for an endpoint
+//returning up to maxLimit elements a page.
+func newAddrStream[T any](api *API, path string, maxLimit int, opts *AddrOptions, fields []string, key func(T) (int, string, int)) (s *addrStream[T], err error) {
+	params, err := opts.params(maxLimit)
+	if err != nil {
+		return
+	}
+	s = &addrStream[T]{api: api, path: path, opts: opts, fields: fields, key: key, pager: newAddrPager(params, maxLimit)}
+	return
+}
+
+//each calls fn with every element of the stream.
+func (s *addrStream[T]) each(c context.Context, fn func(T) error) (err error) {
+	for more := true; more; {
+		if more, err = s.page(c, fn); err != nil {
+			return
+		}
+	}
+	return
+}
+
+//page fetches the next page, calling fn with each of its new
+//elements as it is decoded, and reports whether there is
+//another page.
+func (s *addrStream[T]) page(c context.Context, fn func(T) error) (more bool, err error) {
+	u, err := s.api.buildURL(s.path, s.pager.params)
+	if err != nil {
+		return
+	}
+	var page struct {
+		HasMore bool `json:"hasMore"`
+	}
+	s.pager.begin()
+	each := func(dec *json.Decoder) error {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		height, id, confirmations := s.key(v)
+		if !s.pager.admit(height, id) || s.opts != nil && s.opts.ConfirmationsMax != 0 && confirmations > s.opts.ConfirmationsMax {
+			return nil
+		}
+		return fn(v)
+	}
+	arrays := make(map[string]func(*json.Decoder) error)
+	for _, v := range s.fields {
+		arrays[v] = each
+	}
+	if err = s.api.streamObject(c, u, arrays, &page); err != nil {
+		return
+	}
+	return s.pager.next(page.HasMore)
+}
+
+//addrPager pages through an address's history with "before",
+//dropping the elements repeated across pages: each page asks for
+//the lowest height of the last one again, in case its block didn't
+//fit, and unconfirmed elements may come with any page. A block
+//holding more elements than a page is asked for again with the
+//largest page; if it doesn't fit in that either, paging fails
+//rather than skip part of it.
+type addrPager struct {
+	params map[string]string
+	//maxLimit is the largest page of the endpoint
+	maxLimit int
+	//seen holds the ids at the lowest height of the last page,
+	//pending the unconfirmed ids
+	seen, pending map[string]bool
+	boundary      int
+	//fresh counts the new elements of the current page, low
+	//is its lowest height and lowSeen the ids at that height
+	fresh, low int
+	lowSeen    map[string]bool
+}
+
+//newAddrPager returns a pager starting with params, for
+//an endpoint returning up to maxLimit elements a page.
+func newAddrPager(params map[string]string, maxLimit int) *addrPager {
+	return &addrPager{params: params, maxLimit: maxLimit, seen: make(map[string]bool), pending: make(map[string]bool)}
+}
+
+//begin starts a new page.
+func (p *addrPager) begin() {
+	p.fresh, p.low, p.lowSeen = 0, 0, make(map[string]bool)
+}
+
+//admit records an element of the page, reporting whether
+//it wasn't seen on an earlier page.
+func (p *addrPager) admit(height int, id string) bool {
+	if height > 0 && (p.low == 0 || height <= p.low) {
+		if height != p.low {
+			p.low, p.lowSeen = height, make(map[string]bool)
+		}
+		p.lowSeen[id] = true
+	}
+	if p.seen[id] || p.pending[id] {
+		return false
+	}
+	if height <= 0 {
+		p.pending[id] = true
+	}
+	p.fresh++
+	return true
+}
+
+//next sets the parameters of the page after the current one,
+//reporting whether there is one.
+func (p *addrPager) next(hasMore bool) (more bool, err error) {
+	if !hasMore {
+		return false, nil
+	}
+	if p.low == 0 {
+		//the page only held unconfirmed elements: move on to
+		//the confirmed ones, as any "before" leaves them out
+		if _, ok := p.params["before"]; ok || p.fresh == 0 {
+			return false, nil
+		}
+		p.params["before"] = strconv.Itoa(math.MaxInt32)
+		return true, nil
+	}
+	switch {
+	case p.fresh == 0:
+		//the whole page was at the last height, so its block
+		//holds more than a page: ask for it again in the
+		//largest page, and keep that size from then on
+		max := strconv.Itoa(p.maxLimit)
+		if p.params["limit"] == max {
+			err = errors.New("addrPager: the block at height " + strconv.Itoa(p.low) + " holds more than " + max + " elements of the address, which can't be paged through")
+			return false, err
+		}
+		p.params["limit"] = max
+		return true, nil
+	case p.low == p.boundary:
+		p.params["before"] = strconv.Itoa(p.low + 1)
+		for k := range p.lowSeen {
+			p.seen[k] = true
+		}
+	default:
+		p.params["before"] = strconv.Itoa(p.low + 1)
+		p.seen = p.lowSeen
+	}
+	p.boundary = p.low
+	return true, nil
+}
+
+//streamObject GETs target, a JSON object, and decodes it as it
+//arrives: the elements of the arrays named in arrays are read one
+//by one through their callbacks, and the other fields decoded
+//into rest, if not nil.
+func (api *API) streamObject(c context.Context, target *url.URL, arrays map[string]func(*json.Decoder) error, rest interface{}) (err error) {
+	req, err := newRequest("GET", target, nil)
+	if err != nil {
+		return
+	}
+	resp, err := api.do(c, req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	if err = expectDelim(dec, '{'); err != nil {
+		return
+	}
+	other := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		each, ok := arrays[name]
+		if !ok {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return err
+			}
+			other[name] = raw
+			continue
+		}
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return errors.New("streamObject: expected an array for \"" + name + "\"")
+		}
+		for dec.More() {
+			if err = each(dec); err != nil {
+				return err
+			}
+		}
+		if err = expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	if err = expectDelim(dec, '}'); err != nil || rest == nil {
+		return
+	}
+	data, err := json.Marshal(other)
+	if err != nil {
+		return
+	}
+	return json.Unmarshal(data, rest)
+}
+
+//expectDelim reads the next token of dec, which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return errors.New("streamObject: expected " + delim.String())
+	}
+	return nil
+}
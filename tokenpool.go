@@ -0,0 +1,312 @@
+package gobcy
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//TokenPool spreads calls over several BlockCypher tokens.
+//Each call goes to the token with the most headroom left in the
+//budgets it draws from, as known from the last Refresh and the
+//calls counted since. A token rejected with a 429 is set aside
+//until its Retry-After (a minute by default) and one rejected
+//with a 401/403 until the next successful Refresh; the call
+//then fails over to another token right away.
+//
+//Hooks, payment forwards and wallets belong to the token that
+//created them, so calls on them stay pinned to it, including
+//Address API calls given a wallet name. Listing them merges the
+//lists of every token, which also teaches the pool the owners
+//of those created elsewhere.
+//A TokenPool can be shared by several APIs.
+type TokenPool struct {
+	mu      sync.Mutex
+	members []*poolMember
+	//owners maps hook and payment forward ids, and walletIDs,
+	//to their token.
+	owners map[string]string
+}
+
+//poolMember is a token of a TokenPool.
+type poolMember struct {
+	token string
+	//usage counts the token's calls against its limits.
+	usage *RateLimiter
+	calls int
+	//benched is when the token may be used again.
+	benched time.Time
+}
+
+//NewTokenPool returns a TokenPool of the given tokens. Until
+//Refresh is called, calls are spread evenly between them.
+func NewTokenPool(tokens ...string) *TokenPool {
+	p := &TokenPool{owners: make(map[string]string)}
+	for _, v := range tokens {
+		p.members = append(p.members, &poolMember{token: v, usage: NewRateLimiter(Usage{})})
+	}
+	return p
+}
+
+//Tokens returns the pool's tokens.
+func (p *TokenPool) Tokens() (tokens []string) {
+	for _, v := range p.members {
+		tokens = append(tokens, v.token)
+	}
+	return
+}
+
+//Refresh checks the usage of every token through api, seeding
+//the pool's counters and bringing back tokens set aside.
+func (p *TokenPool) Refresh(c context.Context, api *API) error {
+	var errs []error
+	for _, m := range p.members {
+		probe := *api
+		probe.Token, probe.Tokens = m.token, nil
+		usage, err := probe.CheckUsage(c)
+		p.mu.Lock()
+		switch {
+		case err == nil:
+			m.usage.Seed(usage)
+			m.benched = time.Time{}
+		case errors.Is(err, ErrUnauthorized):
+			m.benched = time.Now().Add(100 * 365 * 24 * time.Hour)
+		}
+		p.mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+//pick returns the token to send a call drawing from budgets,
+//counting the call, which weighs n calls, against it. A call
+//naming an owned wallet goes to the wallet's token.
+func (p *TokenPool) pick(budgets []string, n int, path string) string {
+	owner := p.walletOwner(path)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var best *poolMember
+	var bestRoom float64
+	for _, m := range p.members {
+		if owner != "" {
+			if m.token == owner {
+				best = m
+				break
+			}
+			continue
+		}
+		room := m.usage.headroom(budgets)
+		switch {
+		case best == nil:
+		case m.benched.After(now) || best.benched.After(now):
+			//prefer available tokens, then the soonest back
+			if !m.benched.Before(best.benched) {
+				continue
+			}
+		case room < bestRoom || room == bestRoom && m.calls >= best.calls:
+			continue
+		}
+		best, bestRoom = m, room
+	}
+	if best == nil {
+		return ""
+	}
+	best.usage.take(budgets, n)
+	best.calls += n
+	return best.token
+}
+
+//size returns the number of tokens in the pool.
+func (p *TokenPool) size() int {
+	return len(p.members)
+}
+
+//bench sets token aside after it failed with err, if err
+//calls for it, and reports whether another token is available
+//to fail over to.
+func (p *TokenPool) bench(token string, err error) bool {
+	var until time.Time
+	now := time.Now()
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		until = now.Add(100 * 365 * 24 * time.Hour)
+	case errors.Is(err, ErrRateLimited):
+		until = now.Add(time.Minute)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			until = now.Add(apiErr.RetryAfter)
+		}
+	default:
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	available := false
+	for _, m := range p.members {
+		if m.token == token {
+			m.benched = until
+		} else if !m.benched.After(now) {
+			available = true
+		}
+	}
+	return available
+}
+
+//owner returns the token that created the given
+//hook, payment forward or wallet, if known.
+func (p *TokenPool) owner(id string) (token string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, ok = p.owners[id]
+	return
+}
+
+//pin records token as the owner of the given hook or
+//payment forward. It is a no-op on a nil pool.
+func (p *TokenPool) pin(id, token string) {
+	if p == nil || id == "" || token == "" {
+		return
+	}
+	p.mu.Lock()
+	p.owners[id] = token
+	p.mu.Unlock()
+}
+
+//walletID is the id under which the owner of
+//the named wallet or HD wallet is kept.
+func walletID(name string) string {
+	return "wallet/" + name
+}
+
+//walletOwner returns the owner of the first known wallet
+//named in an Address API path (batches name several), or "".
+func (p *TokenPool) walletOwner(path string) string {
+	_, rest, ok := strings.Cut(path, "/addrs/")
+	if !ok {
+		return ""
+	}
+	ids, _, _ := strings.Cut(rest, "/")
+	for _, id := range strings.Split(ids, ";") {
+		if token, ok := p.owner(walletID(id)); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+//unpin forgets the owner of a deleted hook, payment forward or wallet.
+func (p *TokenPool) unpin(id string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	delete(p.owners, id)
+	p.mu.Unlock()
+}
+
+//createOwned is postResponse for hooks, payment forwards and
+//wallets, pinning the created object to the token used.
+func (api *API) createOwned(c context.Context, target *url.URL, encTarget interface{}, decTarget interface{}, id func() string) (err error) {
+	req, err := newRequest("POST", target, encTarget)
+	if err != nil {
+		return
+	}
+	if err = api.decodeResponse(c, req, decTarget); err == nil {
+		api.Tokens.pin(id(), req.token)
+	}
+	return
+}
+
+//ownedResponse sends req, which acts on the hook, payment
+//forward or wallet id, with the token that owns it. Without a
+//known owner, each token of the pool is tried in turn until one
+//doesn't answer with a 404 or 401.
+func (api *API) ownedResponse(c context.Context, id string, req *request, decTarget interface{}) (err error) {
+	if api.Tokens == nil {
+		return api.decodeResponse(c, req, decTarget)
+	}
+	tokens := api.Tokens.Tokens()
+	if owner, ok := api.Tokens.owner(id); ok {
+		tokens = []string{owner}
+	}
+	req.pinned = true
+	for _, token := range tokens {
+		req.token = token
+		err = api.decodeResponse(c, req, decTarget)
+		if errors.Is(err, ErrUnauthorized) {
+			api.Tokens.bench(token, err)
+		} else if !errors.Is(err, ErrNotFound) {
+			break
+		}
+	}
+	if err == nil {
+		api.Tokens.pin(id, req.token)
+	}
+	return
+}
+
+//deleteOwned deletes the hook, payment forward or wallet id
+//at target with ownedResponse, then forgets its owner.
+func (api *API) deleteOwned(c context.Context, id string, target *url.URL) (err error) {
+	req, err := newRequest("DELETE", target, nil)
+	if err != nil {
+		return
+	}
+	if err = api.ownedResponse(c, id, req, nil); err == nil {
+		api.Tokens.unpin(id)
+	}
+	return
+}
+
+//listOwned lists the hooks or payment forwards at target,
+//merging the lists of every token of the pool. Unauthorized
+//tokens are skipped, unless all of them are.
+func listOwned[T any](c context.Context, api *API, target *url.URL, id func(T) string) (list []T, err error) {
+	return listOwnedIn(c, api, target, func(page []T) []T { return page }, id)
+}
+
+//listOwnedIn is listOwned for lists wrapped in a
+//response of type P, unwrapped by items.
+func listOwnedIn[P, T any](c context.Context, api *API, target *url.URL, items func(P) []T, id func(T) string) (list []T, err error) {
+	if api.Tokens == nil {
+		var page P
+		err = api.getResponse(c, target, &page)
+		list = items(page)
+		return
+	}
+	var authErr error
+	listed := false
+	for _, token := range api.Tokens.Tokens() {
+		req, err := newRequest("GET", target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.token, req.pinned = token, true
+		var page P
+		err = api.decodeResponse(c, req, &page)
+		part := items(page)
+		if errors.Is(err, ErrUnauthorized) {
+			api.Tokens.bench(token, err)
+			authErr = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range part {
+			api.Tokens.pin(id(v), token)
+		}
+		list, listed = append(list, part...), true
+	}
+	if !listed {
+		err = authErr
+	}
+	return
+}
@@ -17,7 +17,7 @@ func (api *API) GetUnTX(c context.Context) (txs []TX, err error) {
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &txs)
+	err = api.getResponse(c, u, &txs)
 	return
 }
 
@@ -28,7 +28,9 @@ func (api *API) GetTX(c context.Context, hash string, params map[string]string)
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &tx)
+	err = api.getCached(c, u, &tx, false, api.keepConfirmed(func() int { return tx.Confirmations }))
+	tx.NextInputs = stripToken(tx.NextInputs)
+	tx.NextOutputs = stripToken(tx.NextOutputs)
 	return
 }
 
@@ -41,12 +43,13 @@ func (api *API) GetTXConf(c context.Context, hash string) (conf TXConf, err erro
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &conf)
+	err = api.getResponse(c, u, &conf)
 	return
 }
 
 //TempNewTX creates a simple template transaction, suitable for
 //use in NewTX. Takes an input/output address and amount.
+//Use ValidateTX to check the addresses before calling NewTX.
 func TempNewTX(inAddr string, outAddr string, amount big.Int) (trans TX) {
 	trans.Inputs = make([]TXInput, 1)
 	trans.Outputs = make([]TXOutput, 1)
@@ -64,7 +67,8 @@ func TempNewTX(inAddr string, outAddr string, amount big.Int) (trans TX) {
 //If inAddr == "", then the returned TX will be a skeleton to
 //send from a multisig address (/series of public keys).
 //n represents the number of valid signatures required, and m
-//is derived from the number of pubkeys.
+//is derived from the number of pubkeys. Like with TempNewTX,
+//ValidateTX checks the address and pubkeys offline.
 func TempMultiTX(inAddr string, outAddr string, amount big.Int, n int, pubkeys []string) (trans TX, err error) {
 	m := len(pubkeys)
 	if inAddr != "" && outAddr != "" {
@@ -106,7 +110,7 @@ func (api *API) NewTX(c context.Context, trans TX, verify bool) (skel TXSkel, er
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &trans, &skel)
+	err = api.idempotentPostResponse(c, u, &trans, &skel)
 	return
 }
 
@@ -153,7 +157,7 @@ func (api *API) SendTX(c context.Context, skel TXSkel) (trans TXSkel, err error)
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &skel, &trans)
+	err = api.idempotentPostResponse(c, u, &skel, &trans)
 	return
 }
 
@@ -164,7 +168,7 @@ func (api *API) PushTX(c context.Context, hex string) (trans TXSkel, err error)
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &map[string]string{"tx": hex}, &trans)
+	err = api.idempotentPostResponse(c, u, &map[string]string{"tx": hex}, &trans)
 	return
 }
 
@@ -176,6 +180,6 @@ func (api *API) DecodeTX(c context.Context, hex string) (trans TX, err error) {
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &map[string]string{"tx": hex}, &trans)
+	err = api.idempotentPostResponse(c, u, &map[string]string{"tx": hex}, &trans)
 	return
 }
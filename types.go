@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"encoding/json"
 	"math/big"
 	"time"
 )
@@ -45,6 +46,9 @@ type Blockchain struct {
 	UnconfirmedCount int       `json:"unconfirmed_count"`
 	LastForkHeight   int       `json:"last_fork_height"`
 	LastForkHash     string    `json:"last_fork_hash"`
+	//Extra holds the fields BlockCypher sent that
+	//Blockchain doesn't know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 //Block represents information about the state
@@ -69,6 +73,9 @@ type Block struct {
 	MerkleRoot   string    `json:"mrkl_root"`
 	TXids        []string  `json:"txids"`
 	NextTXs      string    `json:"next_txids"`
+	//Extra holds the fields BlockCypher sent that
+	//Block doesn't know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 //TX represents information about the state
@@ -107,6 +114,9 @@ type TX struct {
 	NextOutputs   string     `json:"next_outputs,omitempty"`
 	Inputs        []TXInput  `json:"inputs"`
 	Outputs       []TXOutput `json:"outputs"`
+	//Extra holds the fields BlockCypher sent that
+	//TX doesn't know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 //TXInput represents the state of a transaction input
@@ -209,6 +219,9 @@ type Addr struct {
 	TXRefs             []TXRef  `json:"txrefs,omitempty"`
 	UnconfirmedTXRefs  []TXRef  `json:"unconfirmed_txrefs,omitempty"`
 	HasMore            bool     `json:"hasMore,omitempty"`
+	//Extra holds the fields BlockCypher sent that
+	//Addr doesn't know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 //AddrKeychain represents information about a generated
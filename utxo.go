@@ -0,0 +1,95 @@
+package gobcy
+
+import (
+	"math/big"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+//UTXO is an unspent transaction output.
+type UTXO struct {
+	TXID string `json:"txid"`
+	Vout int    `json:"vout"`
+	//Value is in the coin's base units.
+	Value         big.Int `json:"value"`
+	Script        string  `json:"script,omitempty"`
+	Confirmations int     `json:"confirmations"`
+	Address       string  `json:"address"`
+}
+
+//UTXOSet lists the unspent outputs found by ListUnspent,
+//with their totals.
+type UTXOSet struct {
+	UTXOs []UTXO
+	//Total is the value of all UTXOs, and Totals
+	//the value per address.
+	Total  big.Int
+	Totals map[string]*big.Int
+}
+
+//add adds a UTXO to the set.
+func (set *UTXOSet) add(u UTXO) {
+	set.UTXOs = append(set.UTXOs, u)
+	set.Total.Add(&set.Total, &u.Value)
+	if set.Totals[u.Address] == nil {
+		set.Totals[u.Address] = new(big.Int)
+	}
+	set.Totals[u.Address].Add(set.Totals[u.Address], &u.Value)
+}
+
+//ListUnspent returns the unspent outputs of the given addresses
+//(or wallet names) with at least minConf confirmations, across
+//all pages. Addresses are looked up in batches, and the UTXOs
+//come in the order of the addresses, newest first.
+func (api *API) ListUnspent(c context.Context, addrs []string, minConf int) (set UTXOSet, err error) {
+	set.Totals = make(map[string]*big.Int)
+	opts := &AddrOptions{UnspentOnly: true, IncludeScript: true, ConfirmationsMin: minConf, Limit: 2000}
+	results, err := api.GetAddrs(c, addrs, opts)
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	add := func(owner string, ref TXRef) {
+		key := ref.TXHash + ":" + strconv.Itoa(ref.TXOutputN)
+		if ref.TXOutputN < 0 || ref.Spent || ref.Confirmations < minConf || seen[key] {
+			return
+		}
+		seen[key] = true
+		u := UTXO{TXID: ref.TXHash, Vout: ref.TXOutputN, Script: ref.Script, Confirmations: ref.Confirmations, Address: ref.Address}
+		u.Value.Set(&ref.Value)
+		if u.Address == "" {
+			u.Address = owner
+		}
+		set.add(u)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			return set, r.Err
+		}
+		if r.Value.HasMore {
+			//walk the whole history of this one
+			cur := api.NewAddrCursor(c, addrs[i], opts)
+			for cur.Next() {
+				add(addrs[i], cur.Value())
+			}
+			if err = cur.Err(); err != nil {
+				return
+			}
+			continue
+		}
+		for _, ref := range r.Value.UnconfirmedTXRefs {
+			add(addrs[i], ref)
+		}
+		for _, ref := range r.Value.TXRefs {
+			add(addrs[i], ref)
+		}
+	}
+	return
+}
+
+//ListUnspentWallet returns the unspent outputs of the addresses
+//of the named Wallet or HDWallet, like ListUnspent.
+func (api *API) ListUnspentWallet(c context.Context, name string, minConf int) (set UTXOSet, err error) {
+	return api.ListUnspent(c, []string{name}, minConf)
+}
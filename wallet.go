@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -18,22 +19,21 @@ func (api *API) CreateWallet(c context.Context, req Wallet) (wal Wallet, err err
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &req, &wal)
+	err = api.createOwned(c, u, &req, &wal, func() string { return walletID(wal.Name) })
 	return
 }
 
 //ListWallets lists all known Wallets associated with
-//this token/coin/chain.
+//this token/coin/chain, or with any token of API.Tokens.
 func (api *API) ListWallets(c context.Context) (names []string, err error) {
 	u, err := api.buildURL("/wallets", nil)
 	if err != nil {
 		return
 	}
-	jsonResp := new(struct {
+	type walletNames struct {
 		List []string `json:"wallet_names"`
-	})
-	err = getResponse(c, u, jsonResp)
-	names = jsonResp.List
+	}
+	names, err = listOwnedIn(c, api, u, func(v walletNames) []string { return v.List }, walletID)
 	return
 }
 
@@ -45,7 +45,7 @@ func (api *API) GetWallet(c context.Context, name string) (wal Wallet, err error
 	if err != nil {
 		return
 	}
-	err = getResponse(c, u, &wal)
+	err = api.walletResponse(c, name, "GET", u, nil, &wal)
 	return
 }
 
@@ -60,7 +60,7 @@ func (api *API) AddAddrWallet(c context.Context, name string, addrs []string, om
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, &Wallet{Addresses: addrs}, &wal)
+	err = api.walletResponse(c, name, "POST", u, &Wallet{Addresses: addrs}, &wal)
 	return
 }
 
@@ -73,7 +73,7 @@ func (api *API) GetAddrWallet(c context.Context, name string, params map[string]
 		return
 	}
 	var wal Wallet
-	err = getResponse(c, u, &wal)
+	err = api.walletResponse(c, name, "GET", u, nil, &wal)
 	addrs = wal.Addresses
 	return
 }
@@ -86,7 +86,7 @@ func (api *API) DeleteAddrWallet(c context.Context, name string, addrs []string)
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.walletResponse(c, name, "DELETE", u, nil, nil)
 	return
 }
 
@@ -98,7 +98,7 @@ func (api *API) GenAddrWallet(c context.Context, name string) (wal Wallet, addr
 	if err != nil {
 		return
 	}
-	err = postResponse(c, u, nil, &struct {
+	err = api.walletResponse(c, name, "POST", u, nil, &struct {
 		*Wallet
 		*AddrKeychain
 	}{&wal, &addr})
@@ -112,6 +112,17 @@ func (api *API) DeleteWallet(c context.Context, name string) (err error) {
 	if err != nil {
 		return
 	}
-	err = deleteResponse(c, u)
+	err = api.deleteOwned(c, walletID(name), u)
+	return
+}
+
+//walletResponse sends a request acting on the named Wallet or
+//HDWallet with the token that owns it, like ownedResponse.
+func (api *API) walletResponse(c context.Context, name, method string, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
+	req, err := newRequest(method, target, encTarget)
+	if err != nil {
+		return
+	}
+	err = api.ownedResponse(c, walletID(name), req, decTarget)
 	return
 }